@@ -0,0 +1,269 @@
+package main
+
+import (
+    "errors"
+    "flag"
+    "fmt"
+    "go.uber.org/zap"
+    "go.uber.org/zap/zapcore"
+    "io"
+    "net/url"
+    "regexp"
+    "strings"
+    "time"
+    "webCrawler/crawler"
+    "webCrawler/normalize"
+    "webCrawler/sitemap"
+)
+
+type config struct {
+    startingPoints []string
+    format         string
+    output         string
+    xmlSitemap     sitemap.XmlSitemapOptions
+    brokenLinks    bool
+    crawlTimeout   time.Duration
+    options        sitemap.Options
+}
+
+func usage(fs *flag.FlagSet) func() {
+    return func() {
+        out := fs.Output()
+        fmt.Fprintf(out, "Usage: %s [flags] <starting URL> [<starting URL>...]\n\n", fs.Name())
+        fmt.Fprintf(out, "Crawls the websites reachable from the starting URLs and prints their map.\n\n")
+        fmt.Fprintf(out, "Flags:\n")
+        fs.PrintDefaults()
+    }
+}
+
+// Parses the command line arguments (without the program name). The returned
+// error is flag.ErrHelp when the help was requested.
+func parseArgs(name string, args []string, output io.Writer) (config, error) {
+    conf := config{
+        options: sitemap.DefaultOptions(),
+        xmlSitemap: sitemap.DefaultXmlSitemapOptions(),
+    }
+    opts := &conf.options
+
+    fs := flag.NewFlagSet(name, flag.ContinueOnError)
+    fs.SetOutput(output)
+    fs.Usage = usage(fs)
+
+    fs.IntVar(&opts.Concurrency, "concurrency", opts.Concurrency,
+        "number of documents requested simultaneously")
+    fs.IntVar(&opts.Crawler.MaxDepth, "max-depth", opts.Crawler.MaxDepth,
+        "maximum number of links followed from the starting URL, -1 for no limit")
+    fs.IntVar(&opts.Crawler.MaxPages, "max-pages", opts.Crawler.MaxPages,
+        "maximum number of documents requested, 0 for no limit")
+    fs.IntVar(&opts.Crawler.MaxPagesPerHost, "max-pages-per-host", opts.Crawler.MaxPagesPerHost,
+        "maximum number of documents requested from a single host, 0 for no limit")
+    fs.Float64Var(&opts.Politeness.RequestsPerSecond, "rps", opts.Politeness.RequestsPerSecond,
+        "maximum number of requests per second to a single host, 0 for no limit")
+    fs.DurationVar(&opts.Politeness.MinDelay, "min-delay", opts.Politeness.MinDelay,
+        "minimum time between two requests to a single host")
+    fs.IntVar(&opts.Politeness.MaxConnsPerHost, "max-conns-per-host", opts.Politeness.MaxConnsPerHost,
+        "maximum number of simultaneous requests to a single host, 0 for no limit")
+    fs.StringVar(&conf.format, "format", "text",
+        "output format, one of: " + strings.Join(sitemap.Formats(), ", "))
+    fs.StringVar(&conf.output, "output", "",
+        "file where the output is written, instead of the standard output")
+    fs.StringVar(&conf.xmlSitemap.Dir, "xml-sitemap-dir", "",
+        "also write sitemaps.org XML sitemap files into this directory, in a subdirectory per site when several are crawled")
+    fs.StringVar(&conf.xmlSitemap.BaseUrl, "xml-sitemap-base", conf.xmlSitemap.BaseUrl,
+        "URL the XML sitemap files are published under, the crawled site root by default")
+    fs.BoolVar(&conf.xmlSitemap.Gzip, "xml-sitemap-gzip", conf.xmlSitemap.Gzip,
+        "compress the XML sitemap files with gzip")
+    fs.StringVar(&conf.xmlSitemap.ChangeFreq, "changefreq", conf.xmlSitemap.ChangeFreq,
+        "change frequency of the pages listed in the XML sitemap, e.g. daily or weekly")
+    fs.BoolVar(&conf.brokenLinks, "broken-links", false,
+        "print a report of the links that could not be fetched instead of the site map")
+    fs.BoolVar(&opts.Crawler.DropDuplicates, "drop-duplicates", opts.Crawler.DropDuplicates,
+        "merge the pages with the same contents into the first one found, without following their links")
+    followKinds := fs.String("follow-kinds", "navigation",
+        "comma separated list of the kinds of links followed, from: navigation, resource, form")
+    fs.BoolVar(&opts.Crawler.IgnoreNofollow, "ignore-nofollow", opts.Crawler.IgnoreNofollow,
+        "follow the links marked as nofollow by their rel attribute or a robots meta tag")
+    fs.BoolVar(&opts.Crawler.SkipNoIndexLinks, "skip-noindex-links", opts.Crawler.SkipNoIndexLinks,
+        "do not follow the links of the pages marked as noindex")
+    fs.BoolVar(&opts.RenderScripts, "render-scripts", opts.RenderScripts,
+        "also follow the URLs and routes found in the scripts of the pages, e.g. of single page apps")
+    fs.BoolVar(&opts.CheckExternalLinks, "check-external", opts.CheckExternalLinks,
+        "request the links to other sites once, without crawling them, to check they are alive")
+    logLevel := fs.String("log-level", "info",
+        "minimum level of the logged messages: debug, info, warn, error")
+    fs.DurationVar(&opts.RequestTimeout, "timeout", opts.RequestTimeout,
+        "time limit for each document request, 0 for no limit")
+    fs.DurationVar(&conf.crawlTimeout, "crawl-timeout", 0,
+        "time limit for the whole crawl, 0 for no limit")
+    fs.StringVar(&opts.UserAgent, "user-agent", opts.UserAgent,
+        "user agent sent on requests and used to select the robots.txt rules and robots meta tags")
+    ignoreRobots := fs.Bool("ignore-robots", false,
+        "request documents disallowed by robots.txt files")
+    fs.BoolVar(&opts.SeedFromSitemaps, "sitemap-seeds", opts.SeedFromSitemaps,
+        "also crawl the pages listed in the site sitemaps and report the orphan ones")
+    allowHosts := fs.String("allow-hosts", "",
+        "comma separated list of extra host name globs to crawl when linked, e.g. *.example.com")
+    excludeHosts := fs.String("exclude-hosts", "",
+        "comma separated list of host name globs never crawled")
+    fs.BoolVar(&opts.Scope.Subdomains, "subdomains", opts.Scope.Subdomains,
+        "follow links between a host and its subdomains, e.g. www.example.com and example.com")
+    pathPrefixes := fs.String("path-prefixes", "",
+        "comma separated list of path prefixes, only links to paths starting with one are followed")
+    fs.Var((*listFlag)(&opts.Scope.Allow), "allow-url",
+        "regular expression, only links to URLs matching it are followed; may be repeated")
+    fs.Var((*listFlag)(&opts.Scope.Deny), "deny-url",
+        "regular expression, links to URLs matching it are not followed; may be repeated")
+    normalizeSteps := fs.String("normalize", strings.Join(normalize.DefaultStepNames(), ","),
+        "comma separated list of URL normalization steps, from: " + strings.Join(normalize.StepNames(), ", "))
+    keepParams := fs.String("keep-params", "",
+        "comma separated list of query parameter name globs kept in URLs, the rest are removed")
+    stripParams := fs.String("strip-params", "",
+        "comma separated list of query parameter name globs removed from URLs, e.g. utm_*")
+    fs.BoolVar(&opts.Scope.AnyScheme, "any-scheme", opts.Scope.AnyScheme,
+        "follow links to the same host with a different protocol (http/https)")
+
+    if err := fs.Parse(args); err != nil {
+        return conf, err
+    }
+
+    fail := func(err error) (config, error) {
+        fmt.Fprintf(output, "%s\n\n", err.Error())
+        fs.Usage()
+        return conf, err
+    }
+
+    if fs.NArg() < 1 {
+        return fail(errors.New("at least one starting URL is expected"))
+    }
+    conf.startingPoints = fs.Args()
+
+    for _, startingPoint := range conf.startingPoints {
+        if startingUrl, err := url.ParseRequestURI(startingPoint); err != nil || !startingUrl.IsAbs() {
+            return fail(errors.New("starting URL '" + startingPoint + "' is not a valid absolute URL"))
+        }
+    }
+
+    if opts.Concurrency < 1 {
+        return fail(errors.New("-concurrency should be a positive number"))
+    }
+
+    if opts.Crawler.MaxDepth < -1 {
+        return fail(errors.New("-max-depth should be -1 or greater"))
+    }
+
+    if opts.Crawler.MaxPages < 0 {
+        return fail(errors.New("-max-pages should not be negative"))
+    }
+
+    if opts.Crawler.MaxPagesPerHost < 0 {
+        return fail(errors.New("-max-pages-per-host should not be negative"))
+    }
+
+    if opts.Politeness.RequestsPerSecond < 0 {
+        return fail(errors.New("-rps should not be negative"))
+    }
+
+    if opts.Politeness.MinDelay < 0 {
+        return fail(errors.New("-min-delay should not be negative"))
+    }
+
+    if opts.Politeness.MaxConnsPerHost < 0 {
+        return fail(errors.New("-max-conns-per-host should not be negative"))
+    }
+
+    if opts.RequestTimeout < 0 {
+        return fail(errors.New("-timeout should not be negative"))
+    }
+
+    if conf.crawlTimeout < 0 {
+        return fail(errors.New("-crawl-timeout should not be negative"))
+    }
+
+    if !isOutputFormat(conf.format) {
+        return fail(errors.New("unknown output format '" + conf.format + "'"))
+    }
+
+    if conf.xmlSitemap.ChangeFreq != "" && !sitemap.IsChangeFreq(conf.xmlSitemap.ChangeFreq) {
+        return fail(errors.New("unknown change frequency '" + conf.xmlSitemap.ChangeFreq + "'"))
+    }
+
+    for _, expr := range append(append([]string{}, opts.Scope.Allow...), opts.Scope.Deny...) {
+        if _, err := regexp.Compile(expr); err != nil {
+            return fail(errors.New("invalid URL regular expression '" + expr + "': " + err.Error()))
+        }
+    }
+
+    opts.Crawler.FollowKinds = nil
+    for _, name := range splitList(*followKinds) {
+        kind, isKind := crawler.LinkKindByName(strings.ToLower(name))
+        if !isKind {
+            return fail(errors.New("unknown link kind '" + name + "'"))
+        }
+        opts.Crawler.FollowKinds = append(opts.Crawler.FollowKinds, kind)
+    }
+
+    if len(opts.Crawler.FollowKinds) == 0 {
+        return fail(errors.New("-follow-kinds should list at least one kind of link"))
+    }
+
+    normalizer, err := normalize.FromNames(splitList(*normalizeSteps), splitList(*keepParams), splitList(*stripParams))
+    if err != nil {
+        return fail(err)
+    }
+    opts.Normalizer = normalizer
+
+    var level zapcore.Level
+    if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+        return fail(errors.New("unknown log level '" + *logLevel + "'"))
+    }
+
+    logConfig := zap.NewProductionConfig()
+    logConfig.Level = zap.NewAtomicLevelAt(level)
+    logger, err := logConfig.Build()
+    if err != nil {
+        return fail(err)
+    }
+    opts.Logger = logger
+
+    opts.Scope.IncludeHosts = splitList(*allowHosts)
+    opts.Scope.ExcludeHosts = splitList(*excludeHosts)
+    opts.Scope.PathPrefixes = splitList(*pathPrefixes)
+    opts.RespectRobots = !*ignoreRobots
+
+    return conf, nil
+}
+
+func isOutputFormat(format string) bool {
+    for _, f := range sitemap.Formats() {
+        if f == format {
+            return true
+        }
+    }
+
+    return false
+}
+
+// Flag value collecting every time the flag is given.
+type listFlag []string
+
+func (l *listFlag) String() string {
+    return strings.Join(*l, " ")
+}
+
+func (l *listFlag) Set(value string) error {
+    *l = append(*l, value)
+    return nil
+}
+
+// Splits a comma separated list, ignoring empty elements.
+func splitList(list string) []string {
+    var elems []string
+
+    for _, elem := range strings.Split(list, ",") {
+        if elem = strings.TrimSpace(elem); elem != "" {
+            elems = append(elems, elem)
+        }
+    }
+
+    return elems
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+    "bytes"
+    "flag"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "testing"
+    "time"
+    "webCrawler/crawler"
+)
+
+type invalidArgsTest struct {
+    desc string       // A short description of the test case.
+    args []string     // The command line arguments, without the program name.
+    message string    // Start of the error message expected.
+}
+
+var invalidArgsTests = []invalidArgsTest{
+    {"missing URL", []string{"-max-depth", "2"}, "at least one starting URL"},
+    {"relative URL", []string{"example.com"}, "starting URL 'example.com' is not a valid absolute URL"},
+    {"no concurrency", []string{"-concurrency", "0", "http://example.com"}, "-concurrency"},
+    {"max depth below -1", []string{"-max-depth", "-2", "http://example.com"}, "-max-depth"},
+    {"negative max pages", []string{"-max-pages", "-1", "http://example.com"}, "-max-pages "},
+    {"negative max pages per host", []string{"-max-pages-per-host", "-1", "http://example.com"}, "-max-pages-per-host"},
+    {"negative rps", []string{"-rps", "-0.5", "http://example.com"}, "-rps"},
+    {"negative min delay", []string{"-min-delay", "-1s", "http://example.com"}, "-min-delay"},
+    {"negative max connections", []string{"-max-conns-per-host", "-1", "http://example.com"}, "-max-conns-per-host"},
+    {"negative timeout", []string{"-timeout", "-1s", "http://example.com"}, "-timeout"},
+    {"negative crawl timeout", []string{"-crawl-timeout", "-1m", "http://example.com"}, "-crawl-timeout"},
+    {"unknown format", []string{"-format", "yaml", "http://example.com"}, "unknown output format 'yaml'"},
+    {"unknown change frequency", []string{"-changefreq", "often", "http://example.com"}, "unknown change frequency 'often'"},
+    {"invalid URL regexp", []string{"-deny-url", "(", "http://example.com"}, "invalid URL regular expression '('"},
+    {"unknown link kind", []string{"-follow-kinds", "navigation,video", "http://example.com"}, "unknown link kind 'video'"},
+    {"no link kinds", []string{"-follow-kinds", ",", "http://example.com"}, "-follow-kinds"},
+    {"unknown normalize step", []string{"-normalize", "unknown", "http://example.com"}, ""},
+    {"unknown log level", []string{"-log-level", "verbose", "http://example.com"}, "unknown log level 'verbose'"},
+}
+
+func TestParseArgs_Valid(t *testing.T) {
+    assert := assert.New(t)
+
+    conf, err := parseArgs("webCrawler", []string{
+        "-concurrency", "4",
+        "-max-depth", "-1",
+        "-max-pages", "100",
+        "-format", "json",
+        "-follow-kinds", "navigation,resource",
+        "-crawl-timeout", "1m",
+        "-deny-url", `\.pdf$`,
+        "-ignore-robots",
+        "-log-level", "warn",
+        "http://example.com", "https://blog.example.org/",
+    }, ioutil.Discard)
+
+    assert.Nil(err)
+    assert.Equal([]string{"http://example.com", "https://blog.example.org/"}, conf.startingPoints)
+    assert.Equal("json", conf.format)
+    assert.Equal(time.Minute, conf.crawlTimeout)
+    assert.Equal(4, conf.options.Concurrency)
+    assert.Equal(-1, conf.options.Crawler.MaxDepth)
+    assert.Equal(100, conf.options.Crawler.MaxPages)
+    assert.Equal([]crawler.LinkKind{crawler.Navigation, crawler.Resource}, conf.options.Crawler.FollowKinds)
+    assert.Equal([]string{`\.pdf$`}, conf.options.Scope.Deny)
+    assert.False(conf.options.RespectRobots)
+    assert.NotNil(conf.options.Normalizer)
+    assert.NotNil(conf.options.Logger)
+}
+
+func TestParseArgs_Invalid(t *testing.T) {
+    assert := assert.New(t)
+
+    for _, test := range invalidArgsTests {
+        var output bytes.Buffer
+        _, err := parseArgs("webCrawler", test.args, &output)
+
+        if assert.NotNil(err, "Test '%s' failed. Expected an error", test.desc) {
+            assert.Contains(err.Error(), test.message, "Test '%s' failed", test.desc)
+            assert.Contains(output.String(), "Usage: webCrawler", "Test '%s' failed. Expected the usage", test.desc)
+        }
+    }
+}
+
+func TestParseArgs_Help(t *testing.T) {
+    assert := assert.New(t)
+
+    var output bytes.Buffer
+    _, err := parseArgs("webCrawler", []string{"-h"}, &output)
+
+    assert.Equal(flag.ErrHelp, err)
+    assert.Contains(output.String(), "-max-pages")
+}
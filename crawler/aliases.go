@@ -0,0 +1,233 @@
+package crawler
+
+import (
+    "bytes"
+    "crypto/sha256"
+    "encoding/hex"
+    "go.uber.org/zap"
+    "io"
+    "io/ioutil"
+    "sort"
+)
+
+// Reads the whole body of a document into memory, so that its hash is
+// known before scanning it. Returns a reader of the read contents.
+func readAndHash(body io.ReadCloser) (io.ReadCloser, string, error) {
+    contents, err := ioutil.ReadAll(body)
+    body.Close()
+    if err != nil {
+        return nil, "", err
+    }
+
+    hash := sha256.Sum256(contents)
+
+    return ioutil.NopCloser(bytes.NewReader(contents)), hex.EncodeToString(hash[:]), nil
+}
+
+// Sets the canonical location declared by a document, and schedules the
+// document at that location when it was not found yet.
+func (c ScannerCrawler) setCanonical(state *crawlState, doc *DocInfo, locator Loc) {
+
+    canonicalId, hasId := c.resolver.Resolve(locator, doc.FinalId(doc.DocId))
+    if !hasId {
+        if externalResolver, canResolve := c.resolver.(ExternalResolver); canResolve {
+            doc.Canonical, _ = externalResolver.ResolveExternal(locator, doc.FinalId(doc.DocId))
+        }
+
+        c.logger.Debug("Got canonical location - Not in crawl scope",
+            zap.String("DocId", string(doc.DocId)),
+            zap.String("Canonical location", string(locator)))
+        return
+    }
+
+    doc.Canonical = canonicalId
+
+    c.logger.Debug("Got canonical location",
+        zap.String("DocId", string(doc.DocId)),
+        zap.String("Canonical DocId", string(canonicalId)))
+
+    if _, alreadyScanned := c.crawled[canonicalId]; alreadyScanned || canonicalId == doc.FinalId(doc.DocId) {
+        return
+    }
+
+    // The canonical document is the same one, found at the same depth
+    canonicalDoc := DefaultDocInfo(canonicalId)
+    canonicalDoc.Depth = doc.Depth
+    canonicalDoc.Seed = doc.Seed
+
+    c.schedule(state, canonicalDoc)
+}
+
+// Whether other documents can be merged into the one with 'docId', which
+// should have been successfully scanned.
+func (c ScannerCrawler) canMergeInto(docId DocId) bool {
+    doc, exists := c.crawled[docId]
+
+    return exists && doc.completed && doc.SkipReason == "" && doc.Error == "" &&
+        (doc.StatusCode == 0 || (doc.StatusCode >= 200 && doc.StatusCode < 300))
+}
+
+// Gets the document 'doc' should be merged into, if any: its canonical
+// document, or else the first document found with the same contents.
+func (c ScannerCrawler) mergeTarget(doc *DocInfo) (DocId, bool) {
+    if doc.Canonical != "" && doc.Canonical != doc.DocId && c.canMergeInto(doc.Canonical) {
+        return doc.Canonical, true
+    }
+
+    if doc.duplicateOf != "" && c.canMergeInto(doc.duplicateOf) {
+        return doc.duplicateOf, true
+    }
+
+    return "", false
+}
+
+// Gets the document the one with 'docId' ends up merged into, following
+// the merges of the merge targets, or 'docId' itself if it is not merged.
+// Documents merged into each other in a cycle end up merged into the
+// smallest id of the cycle. Results are kept in 'primaries'.
+func (c ScannerCrawler) primaryOf(docId DocId, primaries map [DocId] DocId) DocId {
+    var path []DocId
+    positions := make(map [DocId] int)
+    current := docId
+    var primary DocId
+
+loopOverMergeTargets:
+    for {
+        if known, isKnown := primaries[current]; isKnown {
+            primary = known
+            break loopOverMergeTargets
+        }
+
+        if position, isOnPath := positions[current]; isOnPath {
+            primary = current
+            for _, id := range path[position:] {
+                if id < primary {
+                    primary = id
+                }
+            }
+            break loopOverMergeTargets
+        }
+
+        doc, exists := c.crawled[current]
+        if !exists {
+            primary = current
+            break loopOverMergeTargets
+        }
+
+        target, merges := c.mergeTarget(doc)
+        if !merges {
+            primary = current
+            break loopOverMergeTargets
+        }
+
+        positions[current] = len(path)
+        path = append(path, current)
+        current = target
+    }
+
+    for _, id := range path {
+        primaries[id] = primary
+    }
+    primaries[current] = primary
+
+    return primary
+}
+
+// Whether a completed document may be merged into another one: when it
+// declares another canonical location or has the same contents as another
+// document. Other documents are sent as soon as they are completed.
+func (c ScannerCrawler) mayMerge(doc *DocInfo) bool {
+    return (doc.Canonical != "" && doc.Canonical != doc.DocId) || doc.duplicateOf != ""
+}
+
+// Sends a document through 'outCh', counting it as crawled the first time.
+func (c ScannerCrawler) send(state *crawlState, doc DocInfo, outCh chan DocInfo) {
+    outCh <- doc
+
+    if !state.sentDocs[doc.DocId] {
+        state.sentDocs[doc.DocId] = true
+        state.result.DocsCrawled++
+    }
+}
+
+// Sends the documents held until the end of the crawl through 'outCh', in
+// the order they were completed, after merging the aliases into their
+// primary document. Primary documents already sent are sent again, with
+// their aliases and the links of their aliases.
+func (c ScannerCrawler) sendMerged(state *crawlState, outCh chan DocInfo) {
+    primaries := make(map [DocId] DocId)
+    aliases := make(map [DocId] []DocId)
+    var toSend []DocId
+    queued := make(map [DocId] bool)
+
+    for _, docId := range state.heldDocs {
+        primary := c.primaryOf(docId, primaries)
+        if primary != docId {
+            aliases[primary] = append(aliases[primary], docId)
+        }
+
+        if !queued[primary] {
+            queued[primary] = true
+            toSend = append(toSend, primary)
+        }
+    }
+
+    for _, docId := range toSend {
+        doc := *c.crawled[docId]
+        doc.Aliases = aliases[docId]
+        sort.Slice(doc.Aliases, func(i, j int) bool { return doc.Aliases[i] < doc.Aliases[j] })
+
+        links := append([]LinkInfo{}, doc.LinkDetails...)
+        externalLinks := append([]DocId{}, doc.ExternalLinks...)
+        for _, aliasId := range doc.Aliases {
+            links = appendMissingLinks(links, c.crawled[aliasId].LinkDetails)
+            externalLinks = appendMissing(externalLinks, c.crawled[aliasId].ExternalLinks)
+        }
+
+        doc.Links = nil
+        doc.LinkDetails = nil
+        for _, link := range links {
+            doc.Links = append(doc.Links, link.Target)
+            doc.LinkDetails = append(doc.LinkDetails, link)
+        }
+        if len(externalLinks) > 0 {
+            doc.ExternalLinks = externalLinks
+        }
+
+        c.send(state, doc, outCh)
+    }
+}
+
+// Appends the links of 'from' to targets not in 'to' yet.
+func appendMissingLinks(to []LinkInfo, from []LinkInfo) []LinkInfo {
+    present := make(map [DocId] bool)
+    for _, link := range to {
+        present[link.Target] = true
+    }
+
+    for _, link := range from {
+        if !present[link.Target] {
+            to = append(to, link)
+            present[link.Target] = true
+        }
+    }
+
+    return to
+}
+
+// Appends the ids of 'from' not in 'to' yet.
+func appendMissing(to []DocId, from []DocId) []DocId {
+    present := make(map [DocId] bool)
+    for _, id := range to {
+        present[id] = true
+    }
+
+    for _, id := range from {
+        if !present[id] {
+            to = append(to, id)
+            present[id] = true
+        }
+    }
+
+    return to
+}
@@ -1,12 +1,52 @@
 package crawler
 
+import "context"
+
 type Loc string
 
+// Link from a document to another one.
+type LinkInfo struct {
+    Target DocId
+    LinkAttributes
+}
+
 type DocInfo struct {
-    DocId     DocId
-    Title     string
-    Links     []DocId
-    completed bool
+    DocId         DocId
+    Title         string
+    Links         []DocId
+    // Details of each link in 'Links', in the same order.
+    LinkDetails   []LinkInfo
+    // Links out of the crawl scope, e.g. to other sites, not crawled.
+    ExternalLinks []DocId
+    // Number of links followed from the seed document to reach this one.
+    Depth         int
+    // Seed document the crawl reached this one from.
+    Seed          DocId
+    // Canonical location of the document, as declared by the document
+    // itself. Empty when not declared.
+    Canonical     DocId
+    // Documents merged into this one, because their canonical location
+    // is this document or they have the same contents.
+    Aliases       []DocId
+    // Metadata of the document by key, e.g. its description, in the order
+    // it was found. Nil when the document has none.
+    Metadata      map [string] []string
+    // Whether the document asks not to be indexed, or not to follow any
+    // of its links, with its robots directives.
+    NoIndex       bool
+    NoFollow      bool
+    // Reason why the document was not requested, empty when it was.
+    SkipReason    string
+    // Metadata of the document request.
+    FetchInfo
+    completed     bool
+    // Document found before with the same contents
+    duplicateOf   DocId
+    // Base location of the relative links, as declared by the document
+    base          Loc
+    // Whether the document is not a seed and was redirected out of the
+    // crawl scope, so that its links and canonical location are ignored
+    redirectedOut bool
 }
 
 func DefaultDocInfo(DocId DocId) *DocInfo {
@@ -14,16 +54,51 @@ func DefaultDocInfo(DocId DocId) *DocInfo {
         DocId: DocId,
         Title: "Untitled document",
         Links: nil,
+        LinkDetails: nil,
+        ExternalLinks: nil,
+        Depth: 0,
+        Seed: "",
+        Canonical: "",
+        Aliases: nil,
+        Metadata: nil,
+        NoIndex: false,
+        NoFollow: false,
+        SkipReason: "",
+        FetchInfo: FetchInfo{},
         completed: false,
+        duplicateOf: "",
+        base: "",
     }
 }
 
 type Crawler interface {
-    // Navigates through a website, starting from the document with 'startId'
-    // and sending the found document information through 'outCh'.
+    // Navigates through one or more websites, starting from the documents
+    // in 'seeds' and sending the found document information through 'outCh'.
+    // Every document is crawled once, even when reachable from several seeds.
+    // Documents are sent as they are completed, except the ones that may
+    // be merged into another document, e.g. the document at the canonical
+    // location they declare, which are sent at the end of the crawl. The
+    // documents they are merged into are then sent again, with the merged
+    // ones as aliases. Links to aliases are not replaced.
+    // Links marked as nofollow are recorded but not followed.
     // 'getDocReader' function should have the logic to get a document from its id.
     // 'idFromLoc' function should have the logic to get a document id from the link value.
+    // Once done, 'outCh' is closed and a summary of the crawl is returned.
     Crawl(
-        startId DocId,
-        outCh chan DocInfo)
+        seeds []DocId,
+        outCh chan DocInfo) Result
+
+    // Same as 'Crawl', but stops the crawl once 'ctx' is done. No new
+    // documents are requested after that, the requests in progress are
+    // aborted and 'outCh' is closed after sending the documents completed
+    // so far.
+    //
+    // Documents in 'extraSeeds' are crawled as well, even when no crawled
+    // document links to them, at the same depth as the seeds. They are
+    // attributed to the seed on their host, or to the first seed if none.
+    CrawlContext(
+        ctx context.Context,
+        seeds []DocId,
+        extraSeeds []DocId,
+        outCh chan DocInfo) Result
 }
@@ -0,0 +1,22 @@
+package crawler
+
+import "context"
+
+type Filter interface {
+	// Decides whether the document with 'docId' should be requested. When it
+	// should not, 'reason' describes why. This function may be called
+	// simultaneously from multiple threads.
+	Admit(ctx context.Context, docId DocId) (admitted bool, reason string)
+}
+
+type filterFuncImpl struct {
+	admit func(ctx context.Context, docId DocId) (admitted bool, reason string)
+}
+
+func (ffi filterFuncImpl) Admit(ctx context.Context, docId DocId) (admitted bool, reason string) {
+	return ffi.admit(ctx, docId)
+}
+
+func FilterFunc(admit func(ctx context.Context, docId DocId) (admitted bool, reason string)) Filter {
+	return filterFuncImpl{admit}
+}
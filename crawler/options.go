@@ -0,0 +1,86 @@
+package crawler
+
+import (
+    "go.uber.org/zap"
+    "net/url"
+)
+
+// Tunables of a ScannerCrawler.
+type Options struct {
+    // Size of the buffers used to queue document requests and
+    // scanner messages.
+    DocRequestsBufferSize int
+
+    // Maximum number of links followed from the start document to reach
+    // a document. A negative value means no limit.
+    MaxDepth int
+
+    // Maximum number of documents requested during a crawl, counting the
+    // ones the filter does not admit. Zero or a negative value means no
+    // limit.
+    MaxPages int
+
+    // Maximum number of documents requested from a single host, as
+    // returned by 'HostOf', counted as 'MaxPages'. Zero or a negative value
+    // means no limit.
+    MaxPagesPerHost int
+
+    // Decides which of the found documents are requested. When nil, every
+    // document is requested.
+    Filter Filter
+
+    // Gets the document scanned from every fetched document, e.g. running
+    // its scripts. When nil, documents are scanned as fetched.
+    Renderer Renderer
+
+    // Gets the host serving the document with the given id.
+    HostOf func(docId DocId) string
+
+    // Merge the documents with the same contents into the first one found,
+    // without following the links of the duplicates. Their contents are
+    // compared by hash, reading every document into memory before scanning.
+    DropDuplicates bool
+
+    // Kinds of links followed. Links of other kinds are ignored.
+    FollowKinds []LinkKind
+
+    // Follow the links marked as nofollow, by their 'rel' attribute or by
+    // the robots directives of their document.
+    IgnoreNofollow bool
+
+    // Do not follow the links of the documents with a noindex robots
+    // directive, which are still requested and scanned.
+    SkipNoIndexLinks bool
+
+    Logger *zap.Logger
+}
+
+func DefaultOptions() Options {
+    logger, _ := zap.NewProduction()
+
+    return Options {
+        DocRequestsBufferSize: 1024,
+        MaxDepth: -1,
+        MaxPages: 0,
+        MaxPagesPerHost: 0,
+        Filter: nil,
+        Renderer: nil,
+        HostOf: UrlHost,
+        DropDuplicates: false,
+        FollowKinds: []LinkKind{Navigation},
+        IgnoreNofollow: false,
+        SkipNoIndexLinks: false,
+        Logger: logger,
+    }
+}
+
+// Host of a document id holding a URL. Ids not holding a valid URL
+// are considered to be served by the same host.
+func UrlHost(docId DocId) string {
+    docUrl, err := url.Parse(string(docId))
+    if err != nil {
+        return ""
+    }
+
+    return docUrl.Hostname()
+}
@@ -0,0 +1,39 @@
+package crawler
+
+import "context"
+
+type Renderer interface {
+    // Function called with every fetched document before scanning it, to
+    // get the document actually scanned, e.g. the page built by the scripts
+    // of a fetched HTML shell. This function may be called simultaneously
+    // from multiple threads, and should be aborted once 'ctx' is done.
+    //
+    // The renderer takes over the reader of 'docReader': it should close it
+    // unless it's the reader of the returned document. The returned document
+    // is not scanned when an error is returned.
+    Render(ctx context.Context, docReader DocReader) (DocReader, error)
+}
+
+type rendererFuncImpl struct {
+    render func(ctx context.Context, docReader DocReader) (DocReader, error)
+}
+
+func (rfi rendererFuncImpl) Render(ctx context.Context, docReader DocReader) (DocReader, error) {
+    return rfi.render(ctx, docReader)
+}
+
+func RendererFunc(render func(ctx context.Context, docReader DocReader) (DocReader, error)) Renderer {
+    return rendererFuncImpl{render}
+}
+
+// Renderer returning the fetched documents as they are, so that they are
+// scanned as fetched.
+type passThroughRenderer struct {}
+
+func PassThroughRenderer() Renderer {
+    return passThroughRenderer{}
+}
+
+func (passThroughRenderer) Render(ctx context.Context, docReader DocReader) (DocReader, error) {
+    return docReader, nil
+}
@@ -1,21 +1,25 @@
 package crawler
 
-import "io"
+import "context"
 
 type Requester interface {
 	// Function called to request a reader to the document with 'docId'. This function
-	// may be called simultaneously from multiple threads.
-	Request(docId DocId) (io.ReadCloser, error)
+	// may be called simultaneously from multiple threads. The request, including
+	// reading the document, should be aborted once 'ctx' is done.
+	//
+	// The response carries the metadata of the request even when an error is
+	// returned, and its body is nil when the document should not be scanned.
+	Request(ctx context.Context, docId DocId) (Response, error)
 }
 
 type requesterFuncImpl struct {
-	request func(docId DocId) (io.ReadCloser, error)
+	request func(ctx context.Context, docId DocId) (Response, error)
 }
 
-func (rfi requesterFuncImpl) Request(docId DocId) (io.ReadCloser, error) {
-	return rfi.request(docId)
+func (rfi requesterFuncImpl) Request(ctx context.Context, docId DocId) (Response, error) {
+	return rfi.request(ctx, docId)
 }
 
-func RequesterFunc(request func(docId DocId) (io.ReadCloser, error)) Requester {
+func RequesterFunc(request func(ctx context.Context, docId DocId) (Response, error)) Requester {
 	return requesterFuncImpl{request}
 }
\ No newline at end of file
@@ -2,8 +2,9 @@ package crawler
 
 type Resolver interface {
 	// Gets the id of the document refered to when using 'locator'
-	// inside the document with id 'fromId'. This function may
-	// be called simultaneously from multiple threads.
+	// inside the document with id 'fromId'. For redirected documents,
+	// 'fromId' is the id requested, not the one redirected to. This
+	// function may be called simultaneously from multiple threads.
 	Resolve(locator Loc, fromId DocId) (id DocId, hasId bool)
 }
 
@@ -17,4 +18,25 @@ func (rfi ResolverFuncImpl) Resolve(locator Loc, fromId DocId) (id DocId, hasId
 
 func ResolverFunc(resolve func(locator Loc, fromId DocId) (id DocId, hasId bool)) Resolver {
 	return ResolverFuncImpl{resolve}
-}
\ No newline at end of file
+}
+// Optional interface of the resolvers leaving some links out of the crawl,
+// e.g. the ones to other sites. The ids of those links are kept in the
+// document information as external links.
+type ExternalResolver interface {
+	// Gets the id of a link that 'Resolve' left out of the crawl, if it
+	// is an external link. This function may be called simultaneously
+	// from multiple threads.
+	ResolveExternal(locator Loc, fromId DocId) (id DocId, isExternal bool)
+}
+
+// Optional interface of the resolvers supporting documents that declare a
+// base location, e.g. with an HTML <base> element, their relative links
+// being relative to it instead of to the document.
+type BaseResolver interface {
+	// Gets the locator equivalent to 'locator' inside the document with id
+	// 'fromId' when it declares 'base' as its base location, to be resolved
+	// with 'Resolve' as if found in the document. Returns false when 'base'
+	// is not valid. This function may be called simultaneously from
+	// multiple threads.
+	ResolveBase(locator Loc, base Loc, fromId DocId) (resolved Loc, isValid bool)
+}
@@ -0,0 +1,52 @@
+package crawler
+
+import (
+    "io"
+    "time"
+)
+
+// Metadata of a document request.
+type FetchInfo struct {
+    // Status of the response, e.g. the HTTP status code. Zero when the
+    // request failed before getting a response.
+    StatusCode int
+
+    // Documents the request was redirected to, in order, the last one being
+    // the document actually fetched. Empty when there were no redirects.
+    RedirectChain []DocId
+
+    // Media type of the document, e.g. "text/html; charset=utf-8".
+    ContentType string
+
+    // Time the document was last modified, zero when unknown.
+    LastModified time.Time
+
+    // Error that prevented fetching the document, empty on success.
+    Error string
+
+    // Robots directives sent with the document, e.g. in an X-Robots-Tag
+    // HTTP header. Only the directives applying to every crawler.
+    RobotsTag []string
+
+    // Hash of the document contents, only set when the crawler looks for
+    // duplicate documents.
+    ContentHash string
+}
+
+// Result of a document request made by a Requester.
+type Response struct {
+    FetchInfo
+
+    // Reader with the contents of the document. Nil when there's nothing
+    // to scan, e.g. for error pages.
+    Body io.ReadCloser
+}
+
+// Id of the document actually fetched after following the redirects.
+func (fi FetchInfo) FinalId(requestedId DocId) DocId {
+    if len(fi.RedirectChain) == 0 {
+        return requestedId
+    }
+
+    return fi.RedirectChain[len(fi.RedirectChain)-1]
+}
@@ -0,0 +1,65 @@
+package crawler
+
+import "fmt"
+
+type StopReason int
+const (
+    // Every document reachable within the limits was crawled.
+    Completed StopReason = iota
+
+    // The crawl context was done before completing the crawl.
+    Cancelled
+
+    // Some links were not followed because of a crawl limit.
+    LimitReached
+)
+
+func (sr StopReason) String() string {
+    switch (sr) {
+    case Completed:
+        return "Completed"
+    case Cancelled:
+        return "Cancelled"
+    case LimitReached:
+        return "LimitReached"
+    default:
+        return fmt.Sprintf("%d", int(sr))
+    }
+}
+
+type Limit int
+const (
+    MaxDepth Limit = iota
+    MaxPages
+    MaxPagesPerHost
+)
+
+func (l Limit) String() string {
+    switch (l) {
+    case MaxDepth:
+        return "MaxDepth"
+    case MaxPages:
+        return "MaxPages"
+    case MaxPagesPerHost:
+        return "MaxPagesPerHost"
+    default:
+        return fmt.Sprintf("%d", int(l))
+    }
+}
+
+// Summary of a finished crawl.
+type Result struct {
+    StopReason StopReason
+
+    // Limit that stopped the crawl, only meaningful when 'StopReason' is
+    // 'LimitReached'. When several limits skipped links, the one limiting
+    // the crawl the most is reported: MaxPages, then MaxPagesPerHost and
+    // then MaxDepth.
+    Limit Limit
+
+    // Number of documents sent through the output channel.
+    DocsCrawled int
+
+    // Number of links not followed because of each limit.
+    LinksSkipped map [Limit] int
+}
@@ -13,6 +13,20 @@ const (
     Title MessageType = iota
     Link
     EndOfStream
+    Fetched
+    Canonical
+    // Links the document asks not to follow, e.g. with rel="nofollow"
+    NofollowLink
+    // Robots directives declared by the document, e.g. noindex or nofollow
+    Robots
+    // Base location of the relative links of the document
+    Base
+    // Metadata entry of the document, with its key followed by its value,
+    // e.g. "description" and the description of the document
+    Metadata
+    // Reason why the document was not requested, e.g. as the filter of the
+    // crawl does not admit it
+    Skipped
 )
 
 func (mt MessageType) String() string {
@@ -23,32 +37,122 @@ func (mt MessageType) String() string {
         return "Link"
     case EndOfStream:
         return "EndOfStream"
+    case Fetched:
+        return "Fetched"
+    case Canonical:
+        return "Canonical"
+    case NofollowLink:
+        return "NofollowLink"
+    case Robots:
+        return "Robots"
+    case Base:
+        return "Base"
+    case Metadata:
+        return "Metadata"
+    case Skipped:
+        return "Skipped"
     default:
         return fmt.Sprintf("%d", int(mt))
     }
 }
 
+// What a link is for, so that each kind can be followed or not.
+type LinkKind int
+const (
+    // Links to other documents to navigate to, e.g. anchors
+    Navigation LinkKind = iota
+    // Resources embedded into the document, e.g. images or stylesheets
+    Resource
+    // Targets of the forms of the document
+    Form
+)
+
+func (lk LinkKind) String() string {
+    switch (lk) {
+    case Navigation:
+        return "navigation"
+    case Resource:
+        return "resource"
+    case Form:
+        return "form"
+    default:
+        return fmt.Sprintf("%d", int(lk))
+    }
+}
+
+// Gets the link kind with the given name, as returned by 'String'.
+func LinkKindByName(name string) (LinkKind, bool) {
+    for _, kind := range []LinkKind{Navigation, Resource, Form} {
+        if kind.String() == name {
+            return kind, true
+        }
+    }
+
+    return 0, false
+}
+
+// How a document links to another one, as found by the scanner.
+type LinkAttributes struct {
+    // Text of the link, e.g. the text of an anchor
+    Text     string
+    // Advisory title of the link, e.g. the 'title' attribute of an anchor
+    Title    string
+    // Lower case values of the 'rel' attribute of the link
+    Rel      []string
+    // Position of the link among every link of the document, from 1
+    Position int
+}
+
 type Message struct {
     Content []string
     DocId   DocId
     Type    MessageType
+
+    // Kind of the links, only set on 'Link' and 'NofollowLink' messages.
+    Kind    LinkKind
+
+    // Attributes of each link in 'Content', in the same order. Optional,
+    // only set on 'Link' and 'NofollowLink' messages.
+    Attributes []LinkAttributes
+
+    // Metadata of the document request, only set on 'Fetched' messages.
+    Fetch   *FetchInfo
 }
 
 type DocReader struct {
     DocId  DocId
     Reader io.ReadCloser
+
+    // Media type of the document, e.g. "text/html; charset=utf-8". Empty
+    // when unknown.
+    ContentType string
 }
 
 type Scanner interface {
-    // Scans a document and looks for its title, and for
-    // links to other documents.
+    // Scans a document and looks for its title, its canonical
+    // location, its robots directives, its metadata and for links to
+    // other documents.
     //
-    // The title and links are sent via outCh channel,
-    // and once the scan is done an 'EndOfStream' message with empty
-    // content is sent.
+    // The title, canonical location, robots directives, metadata and
+    // links are sent via outCh channel, after the base location of the
+    // links if declared. Links not to be followed are sent in 'NofollowLink'
+    // messages, with the links of a single kind in each message. Once the
+    // scan is done an 'EndOfStream' message with empty content is sent.
     Scan(docReader DocReader, outCh chan Message)
 }
 
+type scannerFuncImpl struct {
+    scan func(docReader DocReader, outCh chan Message)
+}
+
+func (sfi scannerFuncImpl) Scan(docReader DocReader, outCh chan Message) {
+    sfi.scan(docReader, outCh)
+}
+
+func ScannerFunc(scan func(docReader DocReader, outCh chan Message)) Scanner {
+    return scannerFuncImpl{scan}
+}
+
 func EndOfStreamMsg(docId DocId) Message {
     return Message {
         Content: nil,
@@ -57,6 +161,27 @@ func EndOfStreamMsg(docId DocId) Message {
     }
 }
 
+// Message sent by the crawler, before the scanner ones, with the metadata
+// of the request of the document with 'docId'.
+func FetchedMsg(docId DocId, fetch FetchInfo) Message {
+    return Message {
+        Content: nil,
+        DocId: docId,
+        Type: Fetched,
+        Fetch: &fetch,
+    }
+}
+
+// Message sent by the crawler instead of the 'Fetched' one when the
+// document with 'docId' is not requested, with the reason why.
+func SkippedMsg(docId DocId, reason string) Message {
+    return Message {
+        Content: []string{reason},
+        DocId: docId,
+        Type: Skipped,
+    }
+}
+
 func (msg Message) MarshalLogObject(enc zapcore.ObjectEncoder) error {
     _ = enc.AddArray("Content", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
         for i := range msg.Content {
@@ -67,6 +192,9 @@ func (msg Message) MarshalLogObject(enc zapcore.ObjectEncoder) error {
 
     enc.AddString("DocId", string(msg.DocId))
     enc.AddString("Type", msg.Type.String())
+    if msg.Type == Link || msg.Type == NofollowLink {
+        enc.AddString("Kind", msg.Kind.String())
+    }
 
     return nil
 }
\ No newline at end of file
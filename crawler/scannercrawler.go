@@ -1,18 +1,18 @@
 package crawler
 
 import (
+    "context"
     "go.uber.org/zap"
     "webCrawler/threadpool"
 )
 
-const docRequestsBufferSize = 1024
-
 type ScannerCrawler struct {
     pool       threadpool.Pool
     docScanner Scanner
     requester  Requester
     resolver   Resolver
     crawled    map [DocId] *DocInfo
+    options    Options
     logger     *zap.Logger
 }
 
@@ -20,9 +20,25 @@ func New(
     docScanner Scanner,
     requester Requester,
     resolver Resolver,
-    pool threadpool.Pool) Crawler {
+    pool threadpool.Pool,
+    options Options) Crawler {
+
+    logger := options.Logger
+    if logger == nil {
+        logger = zap.NewNop()
+    }
 
-    logger, _ := zap.NewProduction()
+    if options.HostOf == nil {
+        options.HostOf = UrlHost
+    }
+
+    if options.Renderer == nil {
+        options.Renderer = PassThroughRenderer()
+    }
+
+    if options.FollowKinds == nil {
+        options.FollowKinds = []LinkKind{Navigation}
+    }
 
     return &ScannerCrawler{
         pool,
@@ -30,38 +46,77 @@ func New(
         requester,
         resolver,
         make(map [DocId] *DocInfo),
+        options,
         logger,
     }
 }
 
 func (c ScannerCrawler) Crawl(
-    startId DocId,
-    outCh chan DocInfo) {
+    seeds []DocId,
+    outCh chan DocInfo) Result {
 
-    scanResCh := make(chan Message, docRequestsBufferSize)
-    docIdsCh := make(chan DocId, docRequestsBufferSize)
+    return c.CrawlContext(context.Background(), seeds, nil, outCh)
+}
 
-    c.logger.Info("Crawl started", zap.String("Root page", string(startId)))
+func (c ScannerCrawler) CrawlContext(
+    ctx context.Context,
+    seeds []DocId,
+    extraSeeds []DocId,
+    outCh chan DocInfo) Result {
 
-    go c.produceDocs(docIdsCh, scanResCh)
+    scanResCh := make(chan Message, c.options.DocRequestsBufferSize)
+    docIdsCh := make(chan DocId, c.options.DocRequestsBufferSize)
+    producerDoneCh := make(chan struct{})
 
-    docIdsCh <- DocId(startId)
+    c.logger.Info("Crawl started", zap.Strings("Seeds", docIdStrings(seeds)))
 
-    c.consumeDocs(scanResCh, outCh, docIdsCh)
+    go c.produceDocs(ctx, docIdsCh, scanResCh, producerDoneCh)
 
-    c.logger.Debug("Crawl stopping", zap.String("Root page", string(startId)))
+    result := c.consumeDocs(ctx, scanResCh, outCh, docIdsCh, seeds, extraSeeds)
+
+    c.logger.Debug("Crawl stopping", zap.Int("Seeds", len(seeds)))
+
+    // Once no more documents are scheduled, keep reading the scanner
+    // messages of the tasks still running so they don't block, neither
+    // the producer waiting for a worker nor the pool being stopped.
+    drainedCh := make(chan struct{})
+    go func() {
+        for range scanResCh {}
+        close(drainedCh)
+    }()
 
-    close(outCh)
     close(docIdsCh)
-    close(scanResCh)
+    <- producerDoneCh
+
     c.pool.Stop()
+    close(scanResCh)
+    <- drainedCh
+
+    close(outCh)
 
-    c.logger.Info("Crawl stopped", zap.String("Root page", string(startId)))
+    if ctx.Err() != nil {
+        result.StopReason = Cancelled
+
+        c.logger.Info("Crawl cancelled",
+            zap.Int("Seeds", len(seeds)),
+            zap.Error(ctx.Err()))
+    }
+
+    c.logger.Info("Crawl stopped",
+        zap.Int("Seeds", len(seeds)),
+        zap.Stringer("Reason", result.StopReason),
+        zap.Int("Documents", result.DocsCrawled))
+
+    return result
 }
 
 func (c ScannerCrawler) produceDocs(
+    ctx context.Context,
     docIdsInCh chan DocId,
-    scanResCh chan Message) {
+    scanResCh chan Message,
+    doneCh chan struct{}) {
+
+    defer close(doneCh)
 
 loopOverNewDocIds:
     for {
@@ -70,22 +125,72 @@ loopOverNewDocIds:
             break loopOverNewDocIds
         }
 
+        if ctx.Err() != nil {
+            c.logger.Debug("Crawl cancelled - Document not scheduled",
+                zap.String("DocId", string(nextDocId)))
+            continue loopOverNewDocIds
+        }
+
+        if !c.admit(ctx, nextDocId, scanResCh) {
+            continue loopOverNewDocIds
+        }
+
         c.pool.Run("Scanner for " + string(nextDocId), func () {
+            if ctx.Err() != nil {
+                return
+            }
+
             c.logger.Debug("Running task for document scan",
                 zap.String("DocId", string(nextDocId)))
 
-            nextDoc, err := c.requester.Request(nextDocId)
+            resp, err := c.requester.Request(ctx, nextDocId)
+            if err != nil && resp.Body != nil {
+                resp.Body.Close()
+                resp.Body = nil
+            }
+
+            if err == nil && resp.Body != nil && c.options.DropDuplicates {
+                resp.Body, resp.ContentHash, err = readAndHash(resp.Body)
+            }
+
+            nextDocReader := DocReader{
+                DocId: nextDocId,
+                Reader: resp.Body,
+                ContentType: resp.ContentType,
+            }
+
+            if err == nil && resp.Body != nil {
+                nextDocReader, err = c.options.Renderer.Render(ctx, nextDocReader)
+                if err != nil {
+                    resp.Body = nil
+                }
+            }
+
+            fetch := resp.FetchInfo
             if err != nil {
-                c.logger.Error("Error while requesting doc",
+                fetch.Error = err.Error()
+            }
+
+            if err != nil && ctx.Err() != nil {
+                c.logger.Debug("Request aborted by crawl cancellation",
                     zap.String("DocId", string(nextDocId)),
                     zap.Error(err))
             } else {
-                nextDocReader := DocReader{
-                    DocId: nextDocId,
-                    Reader: nextDoc,
+                if err != nil {
+                    c.logger.Error("Error while requesting doc",
+                        zap.String("DocId", string(nextDocId)),
+                        zap.Error(err))
                 }
 
-                c.docScanner.Scan(nextDocReader, scanResCh)
+                scanResCh <- FetchedMsg(nextDocId, fetch)
+
+                if resp.Body == nil {
+                    // Nothing to scan, the document is completed with just
+                    // the metadata of its request
+                    scanResCh <- EndOfStreamMsg(nextDocId)
+                } else {
+                    c.scan(nextDocReader, scanResCh)
+                }
             }
 
             c.logger.Info("Finished task for document scan",
@@ -96,16 +201,110 @@ loopOverNewDocIds:
     }
 }
 
+// Scans a fetched document. When the scanner panics, e.g. on malformed
+// contents, the document is completed with what was scanned so far, so
+// that a single document can't stop the crawl.
+func (c ScannerCrawler) scan(docReader DocReader, scanResCh chan Message) {
+    defer func() {
+        if r := recover(); r != nil {
+            c.logger.Error("Scanner failed",
+                zap.String("DocId", string(docReader.DocId)),
+                zap.Any("Panic", r))
+
+            docReader.Reader.Close()
+            scanResCh <- EndOfStreamMsg(docReader.DocId)
+        }
+    }()
+
+    c.docScanner.Scan(docReader, scanResCh)
+}
+
+// State of a crawl in progress, only used by the thread consuming the
+// scanner messages.
+type crawlState struct {
+    result        Result
+    // Documents scheduled to be requested, in order
+    frontier      []DocId
+    pendingDocs   int
+    requestedDocs int
+    docsPerHost   map [string] int
+    // Documents scanned that may be merged into another one, in the order
+    // they were completed. They are sent once the crawl is done.
+    heldDocs      []DocId
+    // Documents already sent
+    sentDocs      map [DocId] bool
+    // First document found with each content hash
+    contentHashes map [string] DocId
+}
+
 func (c ScannerCrawler) consumeDocs(
+    ctx context.Context,
     scanResInCh chan Message,
     outCh chan DocInfo,
-    docIdsOutCh chan DocId) {
+    docIdsOutCh chan DocId,
+    seeds []DocId,
+    extraSeeds []DocId) Result {
+
+    state := &crawlState{
+        result: Result{
+            StopReason: Completed,
+            LinksSkipped: make(map [Limit] int),
+        },
+        docsPerHost: make(map [string] int),
+        sentDocs: make(map [DocId] bool),
+        contentHashes: make(map [string] DocId),
+    }
+
+    seedOfHost := make(map [string] DocId)
+
+    for _, seedId := range seeds {
+        if _, alreadyScheduled := c.crawled[seedId]; alreadyScheduled {
+            continue
+        }
+
+        seed := DefaultDocInfo(seedId)
+        seed.Seed = seedId
+        c.schedule(state, seed)
+
+        if _, exists := seedOfHost[c.options.HostOf(seedId)]; !exists {
+            seedOfHost[c.options.HostOf(seedId)] = seedId
+        }
+    }
+
+    for _, seedId := range extraSeeds {
+        if _, alreadyScheduled := c.crawled[seedId]; alreadyScheduled || len(seeds) == 0 {
+            continue
+        }
 
-    pendingDocs := 1
+        seed := DefaultDocInfo(seedId)
+        seed.Seed = seeds[0]
+        if hostSeed, exists := seedOfHost[c.options.HostOf(seedId)]; exists {
+            seed.Seed = hostSeed
+        }
+        c.schedule(state, seed)
+    }
 
 loopOverDocScannerMessages:
-    for {
-        msg := <- scanResInCh
+    for state.pendingDocs > 0 {
+        var msg Message
+
+        // Requests from the frontier are only sent when the producer can
+        // take them, so scanner messages keep being consumed meanwhile
+        var nextDocIdsCh chan DocId
+        var nextDocId DocId
+        if len(state.frontier) > 0 {
+            nextDocIdsCh = docIdsOutCh
+            nextDocId = state.frontier[0]
+        }
+
+        select {
+            case nextDocIdsCh <- nextDocId:
+                state.frontier = state.frontier[1:]
+                continue loopOverDocScannerMessages
+            case msg = <- scanResInCh:
+            case <- ctx.Done():
+                break loopOverDocScannerMessages
+        }
 
         doc, exists := c.crawled[msg.DocId]
         if !exists {
@@ -114,24 +313,120 @@ loopOverDocScannerMessages:
         }
 
         switch msg.Type {
+            case Fetched:
+                doc.FetchInfo = *msg.Fetch
+                c.logger.Debug("Got request metadata",
+                    zap.String("DocId", string(doc.DocId)),
+                    zap.Int("Status", doc.StatusCode))
+
+                applyRobotsDirectives(doc, doc.RobotsTag)
+
+                // Seeds keep their scope when redirected, e.g. from http to
+                // https or to the www host, other documents don't
+                if finalId := doc.FinalId(doc.DocId); finalId != doc.DocId && doc.Seed != doc.DocId {
+                    _, inScope := c.resolver.Resolve(Loc(finalId), doc.DocId)
+                    doc.redirectedOut = !inScope
+                }
+
+                if doc.ContentHash == "" {
+                    break
+                }
+
+                if firstId, seen := state.contentHashes[doc.ContentHash]; seen {
+                    doc.duplicateOf = firstId
+                    c.logger.Debug("Got duplicate document",
+                        zap.String("DocId", string(doc.DocId)),
+                        zap.String("Duplicate of", string(firstId)))
+                } else {
+                    state.contentHashes[doc.ContentHash] = doc.DocId
+                }
+
+            case Base:
+                if doc.base == "" {
+                    doc.base = Loc(msg.Content[0])
+                    c.logger.Debug("Got base location",
+                        zap.String("DocId", string(doc.DocId)),
+                        zap.String("Base location", msg.Content[0]))
+                }
+
+            case Canonical:
+                if doc.redirectedOut {
+                    c.logger.Debug("Got canonical location - Document redirected out of crawl scope",
+                        zap.String("DocId", string(doc.DocId)),
+                        zap.String("Canonical location", msg.Content[0]))
+                    break
+                }
+
+                c.setCanonical(state, doc, c.fromBase(doc, Loc(msg.Content[0])))
+
+            case Robots:
+                applyRobotsDirectives(doc, msg.Content)
+                c.logger.Debug("Got robots directives",
+                    zap.String("DocId", string(doc.DocId)),
+                    zap.Strings("Directives", msg.Content))
+
+            case Metadata:
+                if doc.Metadata == nil {
+                    doc.Metadata = make(map [string] []string)
+                }
+                doc.Metadata[msg.Content[0]] = append(doc.Metadata[msg.Content[0]], msg.Content[1:]...)
+
             case Title:
                 doc.Title = msg.Content[0]
                 c.logger.Debug("Got title from Scanner",
                     zap.String("DocId", string(doc.DocId)),
                     zap.String("Title", doc.Title))
 
-            case Link:
+            case Link, NofollowLink:
+                if doc.redirectedOut {
+                    c.logger.Debug("Got links - Document redirected out of crawl scope",
+                        zap.String("DocId", string(doc.DocId)),
+                        zap.String("Final DocId", string(doc.FinalId(doc.DocId))),
+                        zap.Strings("Link locations", msg.Content))
+                    break
+                }
+
+                if !c.followsKind(msg.Kind) {
+                    c.logger.Debug("Got links - Kind not followed",
+                        zap.String("DocId", string(doc.DocId)),
+                        zap.Stringer("Kind", msg.Kind),
+                        zap.Strings("Link locations", msg.Content))
+                    break
+                }
+
+                followReason, follows := c.followsLinks(doc, msg.Type)
+
             loopOverLinks:
-                for _, link := range msg.Content {
-                    linkedId, linkHasId := c.resolver.Resolve(Loc(link), msg.DocId)
-                    if !linkHasId {
+                for i, link := range msg.Content {
+                    locator := c.fromBase(doc, Loc(link))
+                    linkedId, linkHasId := c.resolver.Resolve(locator, doc.FinalId(doc.DocId))
+                    if !linkHasId && c.recordExternal(doc, locator) {
+                        c.logger.Debug("Got link - External",
+                            zap.String("DocId", string(doc.DocId)),
+                            zap.String("Link location", string(link)))
+                        continue loopOverLinks
+                    } else if !linkHasId {
                         c.logger.Debug("Got link - Ignored by 'idFromLoc' function",
                             zap.String("DocId", string(doc.DocId)),
                             zap.String("Link location", string(link)))
                         continue loopOverLinks
                     }
 
+                    linkInfo := LinkInfo{Target: linkedId}
+                    if i < len(msg.Attributes) {
+                        linkInfo.LinkAttributes = msg.Attributes[i]
+                    }
+
                     doc.Links = append(doc.Links, linkedId)
+                    doc.LinkDetails = append(doc.LinkDetails, linkInfo)
+
+                    if !follows {
+                        c.logger.Debug("Got link - Not followed " + followReason,
+                            zap.String("DocId", string(doc.DocId)),
+                            zap.String("Link location", string(link)))
+
+                        continue loopOverLinks
+                    }
 
                     if _, alreadyScanned := c.crawled[linkedId]; alreadyScanned {
                         c.logger.Debug("Got link - Already scanned",
@@ -142,26 +437,209 @@ loopOverDocScannerMessages:
                         continue loopOverLinks
                     }
 
-                    c.crawled[linkedId] = DefaultDocInfo(linkedId)
-                    docIdsOutCh <- linkedId
+                    linkedDoc := DefaultDocInfo(linkedId)
+                    linkedDoc.Depth = doc.Depth + 1
+                    linkedDoc.Seed = doc.Seed
 
-                    c.logger.Debug("Got link - Requested",
-                        zap.String("DocId", string(doc.DocId)),
-                        zap.String("Link location", string(link)))
-
-                    pendingDocs++
+                    if c.schedule(state, linkedDoc) {
+                        c.logger.Debug("Got link - Requested",
+                            zap.String("DocId", string(doc.DocId)),
+                            zap.String("Link location", string(link)))
+                    }
                 }
 
+            case Skipped:
+                doc.SkipReason = msg.Content[0]
+                c.logger.Debug("Got skip reason",
+                    zap.String("DocId", string(doc.DocId)),
+                    zap.String("Reason", doc.SkipReason))
+
             case EndOfStream:
                 doc.completed = true
-                outCh <- *c.crawled[msg.DocId]
+
+                if c.mayMerge(doc) {
+                    state.heldDocs = append(state.heldDocs, doc.DocId)
+                } else {
+                    c.send(state, *doc, outCh)
+                }
 
                 c.logger.Sync()
 
-                pendingDocs--
-                if pendingDocs == 0 {
-                    break loopOverDocScannerMessages
-                }
+                state.pendingDocs--
         }
     }
+
+    c.sendMerged(state, outCh)
+
+    for _, limit := range []Limit{MaxPages, MaxPagesPerHost, MaxDepth} {
+        if state.result.LinksSkipped[limit] > 0 {
+            state.result.StopReason = LimitReached
+            state.result.Limit = limit
+            break
+        }
+    }
+
+    return state.result
+}
+
+// Adds a new document to the frontier, unless it exceeds a crawl limit.
+// Returns whether it was added.
+func (c ScannerCrawler) schedule(state *crawlState, doc *DocInfo) bool {
+
+    host := c.options.HostOf(doc.DocId)
+
+    if limit, exceeded := c.exceededLimit(doc.Depth, state.requestedDocs, state.docsPerHost[host]); exceeded {
+        state.result.LinksSkipped[limit]++
+
+        c.logger.Debug("Document not requested - Crawl limit",
+            zap.String("DocId", string(doc.DocId)),
+            zap.Stringer("Limit", limit))
+        return false
+    }
+
+    c.crawled[doc.DocId] = doc
+
+    state.frontier = append(state.frontier, doc.DocId)
+    state.pendingDocs++
+    state.requestedDocs++
+    state.docsPerHost[host]++
+
+    return true
+}
+
+// Checks whether the links of 'doc' sent in a message of 'msgType' are
+// followed. If not, returns the reason why.
+func (c ScannerCrawler) followsLinks(doc *DocInfo, msgType MessageType) (string, bool) {
+    switch {
+        case doc.duplicateOf != "":
+            return "from duplicate document", false
+        case !c.options.IgnoreNofollow && (msgType == NofollowLink || doc.NoFollow):
+            return "as nofollow", false
+        case c.options.SkipNoIndexLinks && doc.NoIndex:
+            return "from noindex document", false
+        default:
+            return "", true
+    }
+}
+
+// Whether the links of 'kind' are followed.
+func (c ScannerCrawler) followsKind(kind LinkKind) bool {
+    for _, followedKind := range c.options.FollowKinds {
+        if followedKind == kind {
+            return true
+        }
+    }
+
+    return false
+}
+
+// Sets the robots flags of a document from the directives it declares.
+func applyRobotsDirectives(doc *DocInfo, directives []string) {
+    for _, directive := range directives {
+        switch directive {
+            case "noindex":
+                doc.NoIndex = true
+            case "nofollow":
+                doc.NoFollow = true
+            case "none":
+                doc.NoIndex = true
+                doc.NoFollow = true
+        }
+    }
+}
+
+// Gets the locator equivalent to 'locator' inside 'doc' once the base
+// location of the document is applied, if the resolver supports it. The
+// base location of a redirected document is the document it was redirected
+// to, unless it declares one.
+func (c ScannerCrawler) fromBase(doc *DocInfo, locator Loc) Loc {
+    base := doc.base
+    if finalId := doc.FinalId(doc.DocId); base == "" && finalId != doc.DocId {
+        base = Loc(finalId)
+    }
+
+    baseResolver, canResolve := c.resolver.(BaseResolver)
+    if base == "" || !canResolve {
+        return locator
+    }
+
+    resolved, isValid := baseResolver.ResolveBase(locator, base, doc.FinalId(doc.DocId))
+    if !isValid {
+        return locator
+    }
+
+    return resolved
+}
+
+// Adds the link to the external links of the document, if the resolver
+// considers it an external link. Returns whether it was added.
+func (c ScannerCrawler) recordExternal(doc *DocInfo, locator Loc) bool {
+    externalResolver, canResolve := c.resolver.(ExternalResolver)
+    if !canResolve {
+        return false
+    }
+
+    externalId, isExternal := externalResolver.ResolveExternal(locator, doc.FinalId(doc.DocId))
+    if isExternal {
+        doc.ExternalLinks = append(doc.ExternalLinks, externalId)
+    }
+
+    return isExternal
+}
+
+func docIdStrings(ids []DocId) []string {
+    strs := make([]string, len(ids))
+    for i, id := range ids {
+        strs[i] = string(id)
+    }
+
+    return strs
+}
+
+// Checks whether the filter admits requesting a new document. Documents not
+// admitted are completed right away, with the reason they were skipped,
+// without running a task in the pool. Called before scheduling the tasks,
+// as filters may make their own requests, e.g. for the robots.txt file of
+// the host, which would block the thread consuming the scanner messages.
+func (c ScannerCrawler) admit(ctx context.Context, docId DocId, scanResCh chan Message) bool {
+    if c.options.Filter == nil {
+        return true
+    }
+
+    admitted, reason := c.options.Filter.Admit(ctx, docId)
+    if ctx.Err() != nil {
+        c.logger.Debug("Filter aborted by crawl cancellation",
+            zap.String("DocId", string(docId)))
+        return false
+    }
+
+    if !admitted {
+        c.logger.Debug("Document not requested - Not admitted by filter",
+            zap.String("DocId", string(docId)),
+            zap.String("Reason", reason))
+
+        scanResCh <- SkippedMsg(docId, reason)
+        scanResCh <- EndOfStreamMsg(docId)
+    }
+
+    return admitted
+}
+
+// Checks whether requesting a new document with 'depth' exceeds any of the
+// crawl limits, given the number of documents already requested in total
+// and from the host of the new document.
+func (c ScannerCrawler) exceededLimit(depth int, requestedDocs int, requestedFromHost int) (Limit, bool) {
+    if c.options.MaxPages > 0 && requestedDocs >= c.options.MaxPages {
+        return MaxPages, true
+    }
+
+    if c.options.MaxPagesPerHost > 0 && requestedFromHost >= c.options.MaxPagesPerHost {
+        return MaxPagesPerHost, true
+    }
+
+    if c.options.MaxDepth >= 0 && depth > c.options.MaxDepth {
+        return MaxDepth, true
+    }
+
+    return 0, false
 }
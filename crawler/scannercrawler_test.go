@@ -0,0 +1,767 @@
+package crawler
+
+import (
+    "context"
+    "errors"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+    "webCrawler/threadpool"
+)
+
+// Scanner of test documents with the format
+// "title|link1,!link2,...|canonical|directive1,directive2,...", where the
+// canonical location and the robots directives are optional, links
+// starting with ! are nofollow links and links starting with @ are resources.
+type testScanner struct {}
+
+func (testScanner) Scan(r DocReader, outCh chan Message) {
+    contents, _ := ioutil.ReadAll(r.Reader)
+    r.Reader.Close()
+
+    parts := strings.SplitN(string(contents), "|", 4)
+    outCh <- Message{Content: []string{parts[0]}, DocId: r.DocId, Type: Title}
+
+    if len(parts) >= 3 && parts[2] != "" {
+        outCh <- Message{Content: []string{parts[2]}, DocId: r.DocId, Type: Canonical}
+    }
+
+    if len(parts) == 4 {
+        outCh <- Message{Content: strings.Split(parts[3], ","), DocId: r.DocId, Type: Robots}
+    }
+
+    if len(parts) >= 2 && parts[1] != "" {
+        for _, link := range strings.Split(parts[1], ",") {
+            msgType, kind := Link, Navigation
+            if strings.HasPrefix(link, "!") {
+                link, msgType = link[1:], NofollowLink
+            }
+            if strings.HasPrefix(link, "@") {
+                link, kind = link[1:], Resource
+            }
+
+            outCh <- Message{Content: []string{link}, DocId: r.DocId, Type: msgType, Kind: kind}
+        }
+    }
+
+    outCh <- EndOfStreamMsg(r.DocId)
+}
+
+type testDoc struct {
+    contents   string
+    statusCode int
+    err        error
+}
+
+func newTestCrawler(docs map [DocId] testDoc, options Options) Crawler {
+    requester := RequesterFunc(func(ctx context.Context, docId DocId) (Response, error) {
+        doc, exists := docs[docId]
+        if !exists {
+            return Response{FetchInfo: FetchInfo{StatusCode: 404}}, nil
+        }
+
+        if doc.err != nil {
+            return Response{}, doc.err
+        }
+
+        return Response{
+            FetchInfo: FetchInfo{StatusCode: doc.statusCode},
+            Body: ioutil.NopCloser(strings.NewReader(doc.contents)),
+        }, nil
+    })
+
+    resolver := ResolverFunc(func(locator Loc, fromId DocId) (DocId, bool) {
+        return DocId(locator), true
+    })
+
+    pool, _ := threadpool.NewFixed(2)
+
+    options.Logger = nil
+
+    return New(testScanner{}, requester, resolver, pool, options)
+}
+
+func crawlAll(c Crawler, seeds ...DocId) (map [DocId] DocInfo, Result) {
+    outCh := make(chan DocInfo, 16)
+    resultCh := make(chan Result, 1)
+
+    go func() {
+        resultCh <- c.Crawl(seeds, outCh)
+    }()
+
+    docs := make(map [DocId] DocInfo)
+    for doc := range outCh {
+        docs[doc.DocId] = doc
+    }
+
+    return docs, <- resultCh
+}
+
+func TestShouldCompleteDocumentsWithFailedRequests(t *testing.T) {
+    assert := assert.New(t)
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "root": {"Root|a,missing,failing", 200, nil},
+        "a": {"A|root", 200, nil},
+        "failing": {"", 0, errors.New("connection refused")},
+    }, DefaultOptions())
+
+    docs, result := crawlAll(c, "root")
+
+    assert.Equal(Completed, result.StopReason)
+    assert.Equal(4, len(docs), "Expected every linked document to be sent")
+
+    assert.Equal("A", docs["a"].Title)
+    assert.Equal(404, docs["missing"].StatusCode)
+    assert.Equal("connection refused", docs["failing"].Error)
+    assert.Equal([]DocId{"a", "missing", "failing"}, docs["root"].Links)
+}
+
+func TestShouldStopAtMaxDepth(t *testing.T) {
+    assert := assert.New(t)
+
+    options := DefaultOptions()
+    options.MaxDepth = 1
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "root": {"Root|a", 200, nil},
+        "a": {"A|b", 200, nil},
+        "b": {"B|", 200, nil},
+    }, options)
+
+    docs, result := crawlAll(c, "root")
+
+    assert.Equal(LimitReached, result.StopReason)
+    assert.Equal(MaxDepth, result.Limit)
+    assert.Equal(2, len(docs))
+    assert.Equal(1, docs["a"].Depth)
+}
+
+func TestShouldStopAtMaxPages(t *testing.T) {
+    assert := assert.New(t)
+
+    options := DefaultOptions()
+    options.MaxPages = 2
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "root": {"Root|a,b,c", 200, nil},
+        "a": {"A|", 200, nil},
+        "b": {"B|", 200, nil},
+        "c": {"C|", 200, nil},
+    }, options)
+
+    docs, result := crawlAll(c, "root")
+
+    assert.Equal(LimitReached, result.StopReason)
+    assert.Equal(MaxPages, result.Limit)
+    assert.Equal(2, result.LinksSkipped[MaxPages])
+    assert.Equal(2, len(docs))
+    assert.Equal("A", docs["a"].Title)
+}
+
+func TestShouldStopAtMaxPagesPerHost(t *testing.T) {
+    assert := assert.New(t)
+
+    options := DefaultOptions()
+    options.MaxPagesPerHost = 2
+    options.HostOf = func(docId DocId) string {
+        return testHost(string(docId))
+    }
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "one/root": {"Root|one/a,one/b,two/a,two/b", 200, nil},
+        "one/a": {"A|", 200, nil},
+        "one/b": {"B|", 200, nil},
+        "two/a": {"A|", 200, nil},
+        "two/b": {"B|", 200, nil},
+    }, options)
+
+    docs, result := crawlAll(c, "one/root")
+
+    assert.Equal(LimitReached, result.StopReason)
+    assert.Equal(MaxPagesPerHost, result.Limit)
+    assert.Equal(4, len(docs))
+    assert.Contains(docs, DocId("one/a"))
+    assert.NotContains(docs, DocId("one/b"))
+    assert.Contains(docs, DocId("two/b"))
+}
+
+func TestShouldStopWhenCancelled(t *testing.T) {
+    assert := assert.New(t)
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "root": {"Root|a", 200, nil},
+    }, DefaultOptions())
+
+    ctx, cancel := context.WithCancel(context.Background())
+    cancel()
+
+    outCh := make(chan DocInfo, 16)
+    result := c.CrawlContext(ctx, []DocId{"root"}, nil, outCh)
+
+    _, isOpen := <- outCh
+    assert.False(isOpen, "Expected output channel to be closed")
+    assert.Equal(Cancelled, result.StopReason)
+}
+
+func TestShouldStopWhenCancelledWhileScanning(t *testing.T) {
+    assert := assert.New(t)
+
+    startedCh := make(chan struct{}, 4)
+    releaseCh := make(chan struct{})
+
+    // Sends more messages than the buffer takes once the crawl is cancelled
+    scanner := ScannerFunc(func(r DocReader, outCh chan Message) {
+        r.Reader.Close()
+
+        startedCh <- struct{}{}
+        <- releaseCh
+
+        for i := 0; i < 16; i++ {
+            outCh <- Message{Content: []string{"link"}, DocId: r.DocId, Type: Link}
+        }
+        outCh <- EndOfStreamMsg(r.DocId)
+    })
+
+    requester := RequesterFunc(func(ctx context.Context, docId DocId) (Response, error) {
+        return Response{Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+    })
+    resolver := ResolverFunc(func(locator Loc, fromId DocId) (DocId, bool) {
+        return "", false
+    })
+    pool, _ := threadpool.NewFixed(2)
+
+    options := DefaultOptions()
+    options.DocRequestsBufferSize = 1
+    options.Logger = nil
+
+    c := New(scanner, requester, resolver, pool, options)
+
+    ctx, cancel := context.WithCancel(context.Background())
+    resultCh := make(chan Result, 1)
+    go func() {
+        resultCh <- c.CrawlContext(ctx, []DocId{"a", "b", "c", "d"}, nil, make(chan DocInfo, 16))
+    }()
+
+    // Both workers are scanning, and the producer waiting for one of them
+    // with the next document, when the crawl is cancelled
+    <- startedCh
+    <- startedCh
+    time.Sleep(50 * time.Millisecond)
+    cancel()
+    close(releaseCh)
+
+    select {
+        case result := <- resultCh:
+            assert.Equal(Cancelled, result.StopReason)
+        case <- time.After(5 * time.Second):
+            assert.Fail("Expected the cancelled crawl to stop")
+    }
+}
+
+func TestShouldCrawlExtraSeeds(t *testing.T) {
+    assert := assert.New(t)
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "root": {"Root|a", 200, nil},
+        "a": {"A|", 200, nil},
+        "unlinked": {"Unlinked|b", 200, nil},
+        "b": {"B|", 200, nil},
+    }, DefaultOptions())
+
+    outCh := make(chan DocInfo, 16)
+    result := c.CrawlContext(context.Background(), []DocId{"root"}, []DocId{"a", "unlinked"}, outCh)
+
+    docs := make(map [DocId] DocInfo)
+    for doc := range outCh {
+        docs[doc.DocId] = doc
+    }
+
+    assert.Equal(Completed, result.StopReason)
+    assert.Equal(4, len(docs))
+    assert.Equal(0, docs["unlinked"].Depth)
+    assert.Equal(1, docs["b"].Depth)
+    assert.Equal(DocId("root"), docs["b"].Seed)
+}
+
+func TestShouldShareFrontierAcrossSeeds(t *testing.T) {
+    assert := assert.New(t)
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "one": {"One|shared,a", 200, nil},
+        "two": {"Two|shared,b", 200, nil},
+        "shared": {"Shared|", 200, nil},
+        "a": {"A|", 200, nil},
+        "b": {"B|", 200, nil},
+    }, DefaultOptions())
+
+    docs, result := crawlAll(c, "one", "two", "one")
+
+    assert.Equal(Completed, result.StopReason)
+    assert.Equal(5, result.DocsCrawled, "Expected every document to be crawled once")
+    assert.Equal(DocId("one"), docs["a"].Seed)
+    assert.Equal(DocId("two"), docs["b"].Seed)
+    assert.Equal(DocId("two"), docs["two"].Seed)
+    assert.Equal(0, docs["two"].Depth)
+}
+
+type testExternalResolver struct {}
+
+func (testExternalResolver) Resolve(locator Loc, fromId DocId) (DocId, bool) {
+    return DocId(locator), !strings.HasPrefix(string(locator), "ext:")
+}
+
+func (testExternalResolver) ResolveExternal(locator Loc, fromId DocId) (DocId, bool) {
+    return DocId(locator), strings.HasPrefix(string(locator), "ext:")
+}
+
+func TestShouldRecordExternalLinks(t *testing.T) {
+    assert := assert.New(t)
+
+    requester := RequesterFunc(func(ctx context.Context, docId DocId) (Response, error) {
+        return Response{
+            FetchInfo: FetchInfo{StatusCode: 200},
+            Body: ioutil.NopCloser(strings.NewReader("Root|ext:a,ext:b")),
+        }, nil
+    })
+    pool, _ := threadpool.NewFixed(1)
+
+    options := DefaultOptions()
+    options.Logger = nil
+
+    c := New(testScanner{}, requester, testExternalResolver{}, pool, options)
+    docs, _ := crawlAll(c, "root")
+
+    assert.Equal(1, len(docs), "Expected external links not to be crawled")
+    assert.Equal([]DocId{"ext:a", "ext:b"}, docs["root"].ExternalLinks)
+    assert.Empty(docs["root"].Links)
+}
+
+// Resolver of ids with the format "host/path", only following the links
+// to the same host. Locators without a host are relative to the base.
+type testHostResolver struct {}
+
+func testHost(id string) string {
+    return strings.SplitN(id, "/", 2)[0]
+}
+
+func (testHostResolver) Resolve(locator Loc, fromId DocId) (DocId, bool) {
+    return DocId(locator), testHost(string(locator)) == testHost(string(fromId))
+}
+
+func (testHostResolver) ResolveBase(locator Loc, base Loc, fromId DocId) (Loc, bool) {
+    if strings.Contains(string(locator), "/") {
+        return locator, true
+    }
+
+    return Loc(testHost(string(base)) + "/" + string(locator)), true
+}
+
+func TestShouldKeepScopeOfRedirectedDocuments(t *testing.T) {
+    assert := assert.New(t)
+
+    requester := RequesterFunc(func(ctx context.Context, docId DocId) (Response, error) {
+        contents := map [DocId] string{
+            "site/root": "Root|site/go",
+            "site/go": "Landing|deep,other/deeper,site/back",
+            "site/back": "Back|",
+        }[docId]
+
+        fetch := FetchInfo{StatusCode: 200}
+        if docId == "site/go" {
+            fetch.RedirectChain = []DocId{"other/landing"}
+        }
+
+        return Response{FetchInfo: fetch, Body: ioutil.NopCloser(strings.NewReader(contents))}, nil
+    })
+    pool, _ := threadpool.NewFixed(2)
+
+    options := DefaultOptions()
+    options.Logger = nil
+
+    c := New(testScanner{}, requester, testHostResolver{}, pool, options)
+    docs, _ := crawlAll(c, "site/root")
+
+    assert.Equal(2, len(docs), "Expected links of documents redirected to another host not to be crawled")
+    assert.Equal("Landing", docs["site/go"].Title)
+    assert.Empty(docs["site/go"].Links)
+}
+
+func TestShouldFollowRedirectsOfSeeds(t *testing.T) {
+    assert := assert.New(t)
+
+    requester := RequesterFunc(func(ctx context.Context, docId DocId) (Response, error) {
+        contents := map [DocId] string{
+            "old/home": "Home|a,www/b,old/c",
+            "www/a": "A|",
+            "www/b": "B|",
+        }[docId]
+
+        fetch := FetchInfo{StatusCode: 200}
+        if docId == "old/home" {
+            fetch.RedirectChain = []DocId{"www/home"}
+        }
+
+        return Response{FetchInfo: fetch, Body: ioutil.NopCloser(strings.NewReader(contents))}, nil
+    })
+    pool, _ := threadpool.NewFixed(2)
+
+    options := DefaultOptions()
+    options.Logger = nil
+
+    c := New(testScanner{}, requester, testHostResolver{}, pool, options)
+    docs, _ := crawlAll(c, "old/home")
+
+    assert.Equal(3, len(docs), "Expected the links of the host redirected to to be crawled")
+    assert.Equal([]DocId{"www/a", "www/b"}, docs["old/home"].Links)
+    assert.Equal("A", docs["www/a"].Title)
+    assert.Equal("B", docs["www/b"].Title)
+}
+
+func TestShouldMergeDocumentsIntoCanonical(t *testing.T) {
+    assert := assert.New(t)
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "root": {"Root|a?page=1,a?page=2", 200, nil},
+        "a?page=1": {"A|b|a", 200, nil},
+        "a?page=2": {"A|c|a", 200, nil},
+        "a": {"A|b,c", 200, nil},
+        "b": {"B|a?page=1", 200, nil},
+        "c": {"C|", 200, nil},
+    }, DefaultOptions())
+
+    docs, result := crawlAll(c, "root")
+
+    assert.Equal(4, result.DocsCrawled)
+    assert.NotContains(docs, DocId("a?page=1"))
+    assert.Equal([]DocId{"a?page=1", "a?page=2"}, docs["a"].Aliases)
+    assert.Equal(1, docs["a"].Depth)
+    assert.Equal([]DocId{"b", "c"}, docs["a"].Links)
+    assert.Equal([]DocId{"a?page=1", "a?page=2"}, docs["root"].Links, "Expected links to aliases to be kept")
+}
+
+func TestShouldSendDocumentsOnceCompleted(t *testing.T) {
+    assert := assert.New(t)
+
+    // The last document is only requested once the root one was sent
+    rootSent := make(chan struct{})
+    requester := RequesterFunc(func(ctx context.Context, docId DocId) (Response, error) {
+        contents := map [DocId] string{
+            "root": "Root|last",
+            "last": "Last|",
+        }[docId]
+
+        if docId == "last" {
+            select {
+                case <- rootSent:
+                case <- ctx.Done():
+                    return Response{}, ctx.Err()
+            }
+        }
+
+        return Response{
+            FetchInfo: FetchInfo{StatusCode: 200},
+            Body: ioutil.NopCloser(strings.NewReader(contents)),
+        }, nil
+    })
+    resolver := ResolverFunc(func(locator Loc, fromId DocId) (DocId, bool) {
+        return DocId(locator), true
+    })
+    pool, _ := threadpool.NewFixed(2)
+
+    options := DefaultOptions()
+    options.Logger = nil
+
+    c := New(testScanner{}, requester, resolver, pool, options)
+
+    ctx, cancel := context.WithTimeout(context.Background(), 5 * time.Second)
+    defer cancel()
+
+    outCh := make(chan DocInfo)
+    resultCh := make(chan Result, 1)
+    go func() {
+        resultCh <- c.CrawlContext(ctx, []DocId{"root"}, nil, outCh)
+    }()
+
+    var sent []DocId
+    for doc := range outCh {
+        sent = append(sent, doc.DocId)
+        if doc.DocId == "root" {
+            close(rootSent)
+        }
+    }
+
+    assert.Equal(Completed, (<- resultCh).StopReason)
+    assert.Equal([]DocId{"root", "last"}, sent)
+}
+
+func TestShouldDropDuplicates(t *testing.T) {
+    assert := assert.New(t)
+
+    options := DefaultOptions()
+    options.DropDuplicates = true
+    options.MaxDepth = 1
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "root": {"Root|a", 200, nil},
+        "a": {"Root|a", 200, nil},
+    }, options)
+
+    docs, result := crawlAll(c, "root")
+
+    assert.Equal(1, result.DocsCrawled)
+    assert.Equal([]DocId{"a"}, docs["root"].Aliases)
+    assert.NotEmpty(docs["root"].ContentHash)
+}
+
+func TestShouldNotFollowNofollowLinks(t *testing.T) {
+    assert := assert.New(t)
+
+    docs := map [DocId] testDoc{
+        "root": {"Root|a,!b,c", 200, nil},
+        "a": {"A|", 200, nil},
+        "b": {"B|", 200, nil},
+        "c": {"C|d||noindex,nofollow", 200, nil},
+        "d": {"D|", 200, nil},
+    }
+
+    crawled, _ := crawlAll(newTestCrawler(docs, DefaultOptions()), "root")
+
+    assert.Equal(3, len(crawled))
+    assert.Equal([]DocId{"a", "b", "c"}, crawled["root"].Links, "Expected nofollow links to be recorded")
+    assert.True(crawled["c"].NoIndex)
+    assert.True(crawled["c"].NoFollow)
+    assert.Equal([]DocId{"d"}, crawled["c"].Links)
+
+    options := DefaultOptions()
+    options.IgnoreNofollow = true
+
+    crawled, _ = crawlAll(newTestCrawler(docs, options), "root")
+
+    assert.Equal(5, len(crawled))
+}
+
+func TestShouldSkipLinksOfNoIndexDocuments(t *testing.T) {
+    assert := assert.New(t)
+
+    docs := map [DocId] testDoc{
+        "root": {"Root|a", 200, nil},
+        "a": {"A|b||noindex", 200, nil},
+        "b": {"B|", 200, nil},
+    }
+
+    crawled, _ := crawlAll(newTestCrawler(docs, DefaultOptions()), "root")
+
+    assert.Equal(3, len(crawled), "Expected links of noindex documents to be followed by default")
+
+    options := DefaultOptions()
+    options.SkipNoIndexLinks = true
+
+    crawled, _ = crawlAll(newTestCrawler(docs, options), "root")
+
+    assert.Equal(2, len(crawled))
+    assert.True(crawled["a"].NoIndex)
+}
+
+func TestShouldFollowLinksOfFollowedKinds(t *testing.T) {
+    assert := assert.New(t)
+
+    docs := map [DocId] testDoc{
+        "root": {"Root|a,@style.css", 200, nil},
+        "a": {"A|", 200, nil},
+        "style.css": {"|", 200, nil},
+    }
+
+    crawled, _ := crawlAll(newTestCrawler(docs, DefaultOptions()), "root")
+
+    assert.Equal(2, len(crawled))
+    assert.Equal([]DocId{"a"}, crawled["root"].Links, "Expected links of kinds not followed to be ignored")
+
+    options := DefaultOptions()
+    options.FollowKinds = []LinkKind{Navigation, Resource}
+
+    crawled, _ = crawlAll(newTestCrawler(docs, options), "root")
+
+    assert.Equal(3, len(crawled))
+    assert.Equal([]DocId{"a", "style.css"}, crawled["root"].Links)
+}
+
+func TestShouldKeepLinkAttributes(t *testing.T) {
+    assert := assert.New(t)
+
+    resolver := ResolverFunc(func(locator Loc, fromId DocId) (DocId, bool) {
+        return DocId(locator), locator != "ignored"
+    })
+    requester := RequesterFunc(func(ctx context.Context, docId DocId) (Response, error) {
+        return Response{FetchInfo: FetchInfo{StatusCode: 200}, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+    })
+    scanner := ScannerFunc(func(r DocReader, outCh chan Message) {
+        if r.DocId == "root" {
+            outCh <- Message{
+                Content: []string{"ignored", "a"},
+                DocId: r.DocId,
+                Type: Link,
+                Attributes: []LinkAttributes{{Text: "Ignored", Position: 1}, {Text: "A", Position: 2}},
+            }
+        }
+        outCh <- EndOfStreamMsg(r.DocId)
+    })
+
+    pool, _ := threadpool.NewFixed(2)
+    options := DefaultOptions()
+    options.Logger = nil
+
+    docs, _ := crawlAll(New(scanner, requester, resolver, pool, options), "root")
+
+    assert.Equal([]DocId{"a"}, docs["root"].Links)
+    assert.Equal([]LinkInfo{{"a", LinkAttributes{Text: "A", Position: 2}}}, docs["root"].LinkDetails)
+    assert.Nil(docs["a"].LinkDetails)
+}
+
+func TestShouldScanRenderedDocuments(t *testing.T) {
+    assert := assert.New(t)
+
+    options := DefaultOptions()
+    options.Renderer = RendererFunc(func(ctx context.Context, r DocReader) (DocReader, error) {
+        contents, _ := ioutil.ReadAll(r.Reader)
+        r.Reader.Close()
+
+        if r.DocId == "broken" {
+            return DocReader{}, errors.New("render failed")
+        }
+
+        // Shells get the links added by their scripts
+        rendered := strings.Replace(string(contents), "Shell|", "Shell|a,broken", 1)
+        r.Reader = ioutil.NopCloser(strings.NewReader(rendered))
+
+        return r, nil
+    })
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "root": {"Shell|", 200, nil},
+        "a": {"A|", 200, nil},
+        "broken": {"Broken|", 200, nil},
+    }, options)
+
+    docs, _ := crawlAll(c, "root")
+
+    assert.Equal([]DocId{"a", "broken"}, docs["root"].Links)
+    assert.Equal("A", docs["a"].Title)
+    assert.Equal("render failed", docs["broken"].Error)
+    assert.NotEqual("Broken", docs["broken"].Title)
+}
+
+func TestShouldSkipDocumentsNotAdmitted(t *testing.T) {
+    assert := assert.New(t)
+
+    options := DefaultOptions()
+    options.Filter = FilterFunc(func(ctx context.Context, docId DocId) (bool, string) {
+        return docId != "private", "blocked"
+    })
+
+    c := newTestCrawler(map [DocId] testDoc{
+        "root": {"Root|private,a", 200, nil},
+        "private": {"Private|a", 200, nil},
+        "a": {"A|", 200, nil},
+    }, options)
+
+    docs, result := crawlAll(c, "root")
+
+    assert.Equal(Completed, result.StopReason)
+    assert.Equal("blocked", docs["private"].SkipReason)
+    assert.Empty(docs["private"].Links)
+    assert.Equal(0, docs["private"].StatusCode)
+    assert.Equal("A", docs["a"].Title)
+    assert.Equal([]DocId{"private", "a"}, docs["root"].Links)
+}
+
+// Pool recording the ids of the tasks it runs.
+type recordingPool struct {
+    threadpool.Pool
+    mutex   sync.Mutex
+    taskIds []string
+}
+
+func (p *recordingPool) Run(taskId string, task func()) {
+    p.mutex.Lock()
+    p.taskIds = append(p.taskIds, taskId)
+    p.mutex.Unlock()
+
+    p.Pool.Run(taskId, task)
+}
+
+func TestShouldNotRunTasksForDocumentsNotAdmitted(t *testing.T) {
+    assert := assert.New(t)
+
+    requester := RequesterFunc(func(ctx context.Context, docId DocId) (Response, error) {
+        return Response{
+            FetchInfo: FetchInfo{StatusCode: 200},
+            Body: ioutil.NopCloser(strings.NewReader("Root|private,a")),
+        }, nil
+    })
+    resolver := ResolverFunc(func(locator Loc, fromId DocId) (DocId, bool) {
+        return DocId(locator), true
+    })
+    fixed, _ := threadpool.NewFixed(1)
+    pool := &recordingPool{Pool: fixed}
+
+    options := DefaultOptions()
+    options.Logger = nil
+    options.Filter = FilterFunc(func(ctx context.Context, docId DocId) (bool, string) {
+        return docId != "private", "blocked"
+    })
+
+    c := New(testScanner{}, requester, resolver, pool, options)
+    docs, _ := crawlAll(c, "root")
+
+    assert.Equal("blocked", docs["private"].SkipReason)
+    assert.Equal([]string{"Scanner for root", "Scanner for a"}, pool.taskIds)
+}
+
+// Scanner of test documents failing on the ones titled "Broken".
+type failingScanner struct {}
+
+func (failingScanner) Scan(r DocReader, outCh chan Message) {
+    contents, _ := ioutil.ReadAll(r.Reader)
+    if strings.HasPrefix(string(contents), "Broken|") {
+        panic("malformed document")
+    }
+
+    r.Reader = ioutil.NopCloser(strings.NewReader(string(contents)))
+    testScanner{}.Scan(r, outCh)
+}
+
+func TestShouldCompleteDocumentsWhoseScanFails(t *testing.T) {
+    assert := assert.New(t)
+
+    requester := RequesterFunc(func(ctx context.Context, docId DocId) (Response, error) {
+        contents := map [DocId] string{
+            "root": "Root|broken,a",
+            "broken": "Broken|b",
+            "a": "A|",
+        }[docId]
+
+        return Response{
+            FetchInfo: FetchInfo{StatusCode: 200},
+            Body: ioutil.NopCloser(strings.NewReader(contents)),
+        }, nil
+    })
+    resolver := ResolverFunc(func(locator Loc, fromId DocId) (DocId, bool) {
+        return DocId(locator), true
+    })
+    pool, _ := threadpool.NewFixed(2)
+
+    options := DefaultOptions()
+    options.Logger = nil
+
+    c := New(failingScanner{}, requester, resolver, pool, options)
+    docs, result := crawlAll(c, "root")
+
+    assert.Equal(Completed, result.StopReason)
+    assert.Equal(3, len(docs))
+    assert.Equal(200, docs["broken"].StatusCode)
+    assert.Equal("A", docs["a"].Title)
+}
@@ -0,0 +1,117 @@
+package crawler
+
+import (
+    "mime"
+    "net/url"
+    "path"
+    "strings"
+    "sync"
+)
+
+// Scanner choosing the scanner of each document by its media type or by
+// the extension of its id, e.g. to scan HTML pages and XML feeds with
+// different scanners. Documents of unknown type are scanned with the
+// fallback scanner.
+type ScannerRegistry struct {
+    byMediaType map [string] Scanner
+    byExtension map [string] Scanner
+    fallback    Scanner
+    mutex       sync.RWMutex
+}
+
+func NewScannerRegistry(fallback Scanner) *ScannerRegistry {
+    return &ScannerRegistry{
+        byMediaType: make(map [string] Scanner),
+        byExtension: make(map [string] Scanner),
+        fallback: fallback,
+    }
+}
+
+// Makes 'scanner' scan the documents of the type in 'key', replacing the
+// scanner previously registered for it, if any. The key is either a media
+// type, e.g. "text/html", every media type of a top-level type, e.g.
+// "image/*", or an extension starting with a dot, e.g. ".md".
+func (r *ScannerRegistry) Register(key string, scanner Scanner) {
+    r.mutex.Lock()
+    defer r.mutex.Unlock()
+
+    key = strings.ToLower(strings.TrimSpace(key))
+    if strings.HasPrefix(key, ".") {
+        r.byExtension[key] = scanner
+    } else {
+        r.byMediaType[key] = scanner
+    }
+}
+
+// Media types telling nothing about the content of a document, sent by
+// servers not knowing better, e.g. for PDF downloads.
+var genericMediaTypes = map [string] bool{
+    "application/octet-stream": true,
+    "binary/octet-stream": true,
+    "application/download": true,
+    "application/x-download": true,
+}
+
+// Gets the scanner of a document: the one registered for its media type,
+// then for all the media types of its top-level type, then for its
+// extension, or else the fallback scanner. The extension comes first when
+// the media type is a generic one, e.g. "application/octet-stream".
+func (r *ScannerRegistry) ScannerFor(docReader DocReader) Scanner {
+    r.mutex.RLock()
+    defer r.mutex.RUnlock()
+
+    mediaType, _, err := mime.ParseMediaType(docReader.ContentType)
+    if err != nil {
+        mediaType = ""
+    }
+
+    byExtension, hasExtension := r.byExtension[UrlExtension(docReader.DocId)]
+    if hasExtension && genericMediaTypes[mediaType] {
+        return byExtension
+    }
+
+    if scanner, exists := r.byMediaType[mediaType]; exists && mediaType != "" {
+        return scanner
+    }
+
+    if slash := strings.Index(mediaType, "/"); slash >= 0 {
+        if scanner, exists := r.byMediaType[mediaType[:slash] + "/*"]; exists {
+            return scanner
+        }
+    }
+
+    if hasExtension {
+        return byExtension
+    }
+
+    return r.fallback
+}
+
+func (r *ScannerRegistry) Scan(docReader DocReader, outCh chan Message) {
+    r.ScannerFor(docReader).Scan(docReader, outCh)
+}
+
+// Lower case extension of the path of a document id holding a URL, e.g.
+// ".pdf". Empty when it has none or the id is not a valid URL.
+func UrlExtension(docId DocId) string {
+    docUrl, err := url.Parse(string(docId))
+    if err != nil {
+        return ""
+    }
+
+    return strings.ToLower(path.Ext(docUrl.Path))
+}
+
+type nopScanner struct {}
+
+// Scanner of the documents not to be scanned, e.g. images, which are
+// recorded without any title or link.
+func NopScanner() Scanner {
+    return nopScanner{}
+}
+
+func (nopScanner) Scan(docReader DocReader, outCh chan Message) {
+    docReader.Reader.Close()
+
+    outCh <- EndOfStreamMsg(docReader.DocId)
+}
@@ -0,0 +1,51 @@
+package crawler
+
+import (
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "strings"
+    "testing"
+)
+
+// Scanner sending its name as the title of the scanned documents.
+func namedScanner(name string) Scanner {
+    return ScannerFunc(func(r DocReader, outCh chan Message) {
+        r.Reader.Close()
+        outCh <- Message{Content: []string{name}, DocId: r.DocId, Type: Title}
+        outCh <- EndOfStreamMsg(r.DocId)
+    })
+}
+
+func TestShouldChooseScannerByType(t *testing.T) {
+    assert := assert.New(t)
+
+    registry := NewScannerRegistry(namedScanner("fallback"))
+    registry.Register("text/html", namedScanner("html"))
+    registry.Register("Image/*", namedScanner("image"))
+    registry.Register("image/svg+xml", namedScanner("svg"))
+    registry.Register(".MD", namedScanner("markdown"))
+    registry.Register("application/rss+xml", NopScanner())
+    registry.Register("application/octet-stream", namedScanner("binary"))
+
+    scannedBy := func(docId DocId, contentType string) string {
+        outCh := make(chan Message, 2)
+        reader := DocReader{docId, ioutil.NopCloser(strings.NewReader("")), contentType}
+
+        registry.Scan(reader, outCh)
+
+        if msg := <- outCh; msg.Type == Title {
+            return msg.Content[0]
+        }
+        return ""
+    }
+
+    assert.Equal("html", scannedBy("http://example.com/page.md", "text/html; charset=utf-8"))
+    assert.Equal("image", scannedBy("http://example.com/logo", "image/png"))
+    assert.Equal("svg", scannedBy("http://example.com/logo.svg", "image/svg+xml"))
+    assert.Equal("markdown", scannedBy("http://example.com/README.md?raw", ""))
+    assert.Equal("markdown", scannedBy("http://example.com/README.md", "text/plain"))
+    assert.Equal("fallback", scannedBy("http://example.com/page", ""))
+    assert.Equal("markdown", scannedBy("http://example.com/README.md", "application/octet-stream"))
+    assert.Equal("binary", scannedBy("http://example.com/download", "application/octet-stream"))
+    assert.Equal("", scannedBy("http://example.com/feed", "application/rss+xml"), "Expected no title from the no-op scanner")
+}
@@ -0,0 +1,153 @@
+package cssscanner
+
+import (
+    "go.uber.org/zap"
+    "io"
+    "io/ioutil"
+    "regexp"
+    "strings"
+    "webCrawler/crawler"
+)
+
+type Options struct {
+    // Maximum number of links sent in a single message.
+    LinksPerMsg int
+
+    // Maximum number of bytes read from a document. Only the start of
+    // larger documents is scanned.
+    MaxSize int64
+
+    Logger *zap.Logger
+}
+
+func DefaultOptions() Options {
+    logger, _ := zap.NewProduction()
+
+    return Options {
+        LinksPerMsg: 20,
+        MaxSize: 4 * 1024 * 1024,
+        Logger: logger,
+    }
+}
+
+// Scanner of stylesheets, finding the resources they use, i.e. the
+// stylesheets they import and the images and fonts of their url() values.
+type CssScanner struct {
+    options Options
+}
+
+func New(options Options) crawler.Scanner {
+    if options.Logger == nil {
+        options.Logger = zap.NewNop()
+    }
+
+    if options.LinksPerMsg < 1 {
+        options.LinksPerMsg = 1
+    }
+
+    if options.MaxSize <= 0 {
+        options.MaxSize = DefaultOptions().MaxSize
+    }
+
+    return &CssScanner{options}
+}
+
+func (s *CssScanner) Scan(r crawler.DocReader, outCh chan crawler.Message) {
+    logger := s.options.Logger.With(zap.String("DocId", string(r.DocId)))
+
+    contents, err := ioutil.ReadAll(io.LimitReader(r.Reader, s.options.MaxSize))
+    if err != nil {
+        logger.Debug("Error while reading document", zap.Error(err))
+    }
+
+    urls := Urls(string(contents))
+    for start := 0; start < len(urls); start += s.options.LinksPerMsg {
+        end := start + s.options.LinksPerMsg
+        if end > len(urls) {
+            end = len(urls)
+        }
+
+        msg := crawler.Message{
+            Content: urls[start:end],
+            DocId: r.DocId,
+            Type: crawler.Link,
+            Kind: crawler.Resource,
+        }
+
+        logger.Debug("Send links", zap.Object("Msg", msg))
+        outCh <- msg
+    }
+
+    eos := crawler.EndOfStreamMsg(r.DocId)
+
+    logger.Debug("Send EoS", zap.Object("Msg", eos))
+    outCh <- eos
+
+    logger.Sync()
+
+    // The rest of the documents larger than the limit is not read
+    r.Reader.Close()
+}
+
+var commentRegexp = regexp.MustCompile(`(?s)/\*.*?\*/`)
+
+// Matches url() values, quoted or not, and @import rules with a string,
+// e.g. @import "print.css". Only one of the groups is matched.
+var urlRegexp = regexp.MustCompile(
+    `(?i)url\(\s*(?:"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)'|([^)"'\s]*))\s*\)` +
+    `|@import\s+(?:"((?:[^"\\]|\\.)*)"|'((?:[^'\\]|\\.)*)')`)
+
+// Gets the URLs referenced by a stylesheet, or by the contents of a style
+// attribute, in order of appearance and without repetitions. Data URLs and
+// references to fragments of the same document, e.g. SVG filters, are left
+// out, as they are not other documents.
+func Urls(css string) []string {
+    if css == "" {
+        return nil
+    }
+
+    var urls []string
+    found := make(map [string] bool)
+
+    for _, match := range urlRegexp.FindAllStringSubmatch(commentRegexp.ReplaceAllString(css, " "), -1) {
+        var ref string
+        for _, group := range match[1:] {
+            ref += group
+        }
+        ref = unescape(strings.TrimSpace(ref))
+
+        if ref == "" || found[ref] || strings.HasPrefix(ref, "#") || strings.HasPrefix(strings.ToLower(ref), "data:") {
+            continue
+        }
+
+        urls = append(urls, ref)
+        found[ref] = true
+    }
+
+    return urls
+}
+
+// Removes the backslashes escaping characters, e.g. quotes or parentheses,
+// and the escaped new lines. Hexadecimal escapes are kept as they are.
+func unescape(s string) string {
+    if !strings.Contains(s, `\`) {
+        return s
+    }
+
+    var out strings.Builder
+    for i := 0; i < len(s); i++ {
+        if s[i] == '\\' && i + 1 < len(s) && !isHexDigit(s[i + 1]) {
+            i++
+            if s[i] == '\n' {
+                continue
+            }
+        }
+        out.WriteByte(s[i])
+    }
+
+    return out.String()
+}
+
+func isHexDigit(c byte) bool {
+    return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
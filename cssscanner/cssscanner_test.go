@@ -0,0 +1,51 @@
+package cssscanner
+
+import (
+    "bytes"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "testing"
+    "webCrawler/crawler"
+)
+
+func TestUrls(t *testing.T) {
+    assert := assert.New(t)
+
+    css := `@import "print.css" print;
+        @import url('theme.css');
+        /* background: url(commented.png); */
+        body { background: url(  img/bg.png  ) no-repeat, URL("img/a\"b.png"); }
+        @font-face { src: url(fonts/a.woff2) format("woff2"), url(fonts/a.woff2); }
+        .icon { background-image: url(data:image/png;base64,AAAA); filter: url(#blur); }
+        .empty { background: url(); }`
+
+    assert.Equal([]string{"print.css", "theme.css", "img/bg.png", `img/a"b.png`, "fonts/a.woff2"}, Urls(css))
+    assert.Equal([]string{"/hero.jpg"}, Urls("background: url('/hero.jpg') center"))
+    assert.Empty(Urls("color: red"))
+    assert.Empty(Urls(""))
+}
+
+func TestCssScanner_Scan(t *testing.T) {
+    assert := assert.New(t)
+
+    outCh := make(chan crawler.Message)
+    reader := crawler.DocReader{
+        DocId: "DOC_ID",
+        Reader: ioutil.NopCloser(bytes.NewReader([]byte(`@import "a.css"; p { background: url(b.png) } i { background: url(c.png) }`))),
+    }
+
+    options := DefaultOptions()
+    options.LinksPerMsg = 2
+    go New(options).Scan(reader, outCh)
+
+    var links []string
+    for msg := <- outCh; msg.Type != crawler.EndOfStream; msg = <- outCh {
+        assert.Equal(crawler.Link, msg.Type)
+        assert.Equal(crawler.Resource, msg.Kind)
+        assert.LessOrEqual(len(msg.Content), 2)
+
+        links = append(links, msg.Content...)
+    }
+
+    assert.Equal([]string{"a.css", "b.png", "c.png"}, links)
+}
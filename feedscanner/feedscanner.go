@@ -0,0 +1,273 @@
+package feedscanner
+
+import (
+    "bufio"
+    "encoding/xml"
+    "errors"
+    "go.uber.org/zap"
+    "io"
+    "io/ioutil"
+    "strings"
+    "unicode/utf8"
+    "webCrawler/crawler"
+)
+
+type Options struct {
+    // Maximum number of links sent in a single message.
+    LinksPerMsg int
+
+    Logger *zap.Logger
+}
+
+func DefaultOptions() Options {
+    logger, _ := zap.NewProduction()
+
+    return Options {
+        LinksPerMsg: 20,
+        Logger: logger,
+    }
+}
+
+// Scanner of RSS 2.0, RSS 1.0 and Atom feeds, finding the title of the feed
+// and the links of its items.
+type FeedScanner struct {
+    options Options
+}
+
+func New(options Options) crawler.Scanner {
+    if options.Logger == nil {
+        options.Logger = zap.NewNop()
+    }
+
+    if options.LinksPerMsg < 1 {
+        options.LinksPerMsg = 1
+    }
+
+    return &FeedScanner{options}
+}
+
+func (s *FeedScanner) Scan(r crawler.DocReader, outCh chan crawler.Message) {
+    logger := s.options.Logger.With(zap.String("DocId", string(r.DocId)))
+
+    decoder := xml.NewDecoder(r.Reader)
+    decoder.Strict = false
+    decoder.CharsetReader = charsetReader
+
+    unsentLinks := make([]string, 0, s.options.LinksPerMsg)
+    sendLinks := func() {
+        msg := crawler.Message{
+            Content: unsentLinks,
+            DocId: r.DocId,
+            Type: crawler.Link,
+            Kind: crawler.Navigation,
+        }
+
+        logger.Debug("Send links", zap.Object("Msg", msg))
+        outCh <- msg
+
+        unsentLinks = make([]string, 0, s.options.LinksPerMsg)
+    }
+
+    err := scanFeed(decoder, func(title string) {
+        msg := crawler.Message{
+            Content: []string{title},
+            DocId: r.DocId,
+            Type: crawler.Title,
+        }
+
+        logger.Debug("Send title", zap.Object("Msg", msg))
+        outCh <- msg
+    }, func(link string) {
+        logger.Debug("Found link", zap.String("Link", link))
+
+        unsentLinks = append(unsentLinks, link)
+        if len(unsentLinks) == s.options.LinksPerMsg {
+            sendLinks()
+        }
+    })
+    if err != nil {
+        logger.Debug("Stopped scanning feed", zap.Error(err))
+    }
+
+    if len(unsentLinks) != 0 {
+        sendLinks()
+    }
+
+    eos := crawler.EndOfStreamMsg(r.DocId)
+
+    logger.Debug("Send EoS", zap.Object("Msg", eos))
+    outCh <- eos
+
+    logger.Sync()
+
+    // Read until end of file and close
+    _, _ = ioutil.ReadAll(r.Reader)
+    r.Reader.Close()
+}
+
+// Reads a feed, calling 'onTitle' with its title and 'onLink' with the link
+// of each item, in order. Returns an error when the document is not a feed
+// or is not valid, after calling them with what was found so far.
+func scanFeed(decoder *xml.Decoder, onTitle func(title string), onLink func(link string)) error {
+    // Local names of the elements the current one is in, the root first
+    var path []string
+    titleFound := false
+
+loopOverTokens:
+    for {
+        token, err := decoder.Token()
+        if err == io.EOF {
+            break loopOverTokens
+        } else if err != nil {
+            return err
+        }
+
+        switch element := token.(type) {
+            case xml.StartElement:
+                path = append(path, element.Name.Local)
+
+                switch {
+                    case len(path) == 1:
+                        if root := element.Name.Local; root != "rss" && root != "feed" && root != "RDF" {
+                            return errors.New("not a feed: root element is '" + element.Name.Local + "'")
+                        }
+
+                    case isFeedTitle(path) && !titleFound:
+                        // Read up to the end of the element
+                        if title, err := elementText(decoder, element); err == nil && title != "" {
+                            onTitle(title)
+                            titleFound = true
+                        }
+                        path = path[:len(path)-1]
+
+                    case isItemLink(path):
+                        if href, isAtom := atomLinkHref(element); isAtom {
+                            if href != "" {
+                                onLink(href)
+                            }
+                            break
+                        }
+
+                        // RSS links are the text of the element, read up to its end
+                        if link, err := elementText(decoder, element); err == nil && link != "" {
+                            onLink(link)
+                        }
+                        path = path[:len(path)-1]
+                }
+
+            case xml.EndElement:
+                if len(path) > 0 {
+                    path = path[:len(path)-1]
+                }
+        }
+    }
+
+    return nil
+}
+
+// Whether the element at 'path' is the title of the feed.
+func isFeedTitle(path []string) bool {
+    switch len(path) {
+        case 2:
+            // Atom feed title
+            return path[0] == "feed" && path[1] == "title"
+        case 3:
+            // RSS channel title
+            return path[1] == "channel" && path[2] == "title"
+    }
+
+    return false
+}
+
+// Whether the element at 'path' is the link of an item of the feed.
+func isItemLink(path []string) bool {
+    n := len(path)
+    if n < 3 || path[n-1] != "link" {
+        return false
+    }
+
+    switch path[n-2] {
+        case "entry":
+            return path[0] == "feed"
+        case "item":
+            // RSS 2.0 items are in the channel, RSS 1.0 ones next to it
+            return n == 4 && path[1] == "channel" || n == 3 && path[0] == "RDF"
+    }
+
+    return false
+}
+
+// Gets the 'href' of an Atom link to the web page of an entry, which has
+// no 'rel' or an alternate one. RSS links have no 'href'.
+func atomLinkHref(element xml.StartElement) (href string, isAtom bool) {
+    rel := ""
+    for _, attr := range element.Attr {
+        switch attr.Name.Local {
+            case "href":
+                href, isAtom = strings.TrimSpace(attr.Value), true
+            case "rel":
+                rel = strings.TrimSpace(attr.Value)
+        }
+    }
+
+    if rel != "" && rel != "alternate" {
+        return "", isAtom
+    }
+
+    return href, isAtom
+}
+
+// Reads the text of the current element until its end, without the
+// surrounding spaces.
+func elementText(decoder *xml.Decoder, start xml.StartElement) (string, error) {
+    var text string
+    if err := decoder.DecodeElement(&text, &start); err != nil {
+        return "", err
+    }
+
+    return strings.TrimSpace(text), nil
+}
+
+// Converts the feeds in other encodings than UTF-8 into UTF-8. Only the
+// encodings of the ASCII and Latin-1 families are supported.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+    switch strings.ToLower(charset) {
+        case "us-ascii", "ascii":
+            return input, nil
+        case "iso-8859-1", "iso_8859-1", "latin1", "windows-1252":
+            return &latin1Reader{bufio.NewReader(input), nil}, nil
+    }
+
+    return nil, errors.New("unsupported charset '" + charset + "'")
+}
+
+// Reader converting Latin-1 text into UTF-8.
+type latin1Reader struct {
+    input   *bufio.Reader
+    pending []byte
+}
+
+func (r *latin1Reader) Read(p []byte) (int, error) {
+    n := 0
+    for n < len(p) {
+        if len(r.pending) > 0 {
+            copied := copy(p[n:], r.pending)
+            r.pending = r.pending[copied:]
+            n += copied
+            continue
+        }
+
+        b, err := r.input.ReadByte()
+        if err != nil {
+            if n > 0 {
+                return n, nil
+            }
+            return 0, err
+        }
+
+        var encoded [utf8.UTFMax]byte
+        r.pending = append(r.pending[:0], encoded[:utf8.EncodeRune(encoded[:], rune(b))]...)
+    }
+
+    return n, nil
+}
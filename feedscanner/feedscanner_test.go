@@ -0,0 +1,102 @@
+package feedscanner
+
+import (
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "strings"
+    "testing"
+    "webCrawler/crawler"
+)
+
+type feedTest struct {
+    desc          string
+    feed          string
+    expectedTitle string
+    expectedLinks []string
+}
+
+var feedTests = []feedTest{
+    {
+        "RSS 2.0 feed",
+        `<?xml version="1.0" encoding="UTF-8"?>
+        <rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+            <channel>
+                <title> News </title>
+                <link>http://example.com/</link>
+                <atom:link href="http://example.com/feed" rel="self" type="application/rss+xml"/>
+                <image><title>Logo</title><link>http://example.com/</link></image>
+                <item><title>First</title><link>http://example.com/first</link></item>
+                <item><title>Second</title><link> /second </link></item>
+            </channel>
+        </rss>`,
+        "News",
+        []string{"http://example.com/first", "/second"},
+    },
+    {
+        "Atom feed",
+        `<feed xmlns="http://www.w3.org/2005/Atom">
+            <title type="text">Blog</title>
+            <link href="http://example.com/"/>
+            <entry>
+                <title>Post</title>
+                <link rel="edit" href="http://example.com/edit/post"/>
+                <link href="http://example.com/post"/>
+            </entry>
+            <entry><link rel="alternate" href="/other-post"></link></entry>
+        </feed>`,
+        "Blog",
+        []string{"http://example.com/post", "/other-post"},
+    },
+    {
+        "RSS 1.0 feed in Latin-1",
+        "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?>" +
+        `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">
+            <channel><title>Caf` + "\xe9" + `</title></channel>
+            <item><link>http://example.com/item</link></item>
+        </rdf:RDF>`,
+        "Café",
+        []string{"http://example.com/item"},
+    },
+    {
+        "not a feed",
+        `<html><head><title>Page</title></head><body><a href="/a">A</a></body></html>`,
+        "",
+        nil,
+    },
+    {
+        "truncated feed",
+        `<rss><channel><title>Truncated</title><item><link>/first</link></item><item><li`,
+        "Truncated",
+        []string{"/first"},
+    },
+}
+
+func TestFeedScanner_Scan(t *testing.T) {
+    assert := assert.New(t)
+
+    options := DefaultOptions()
+    options.LinksPerMsg = 1
+    scanner := New(options)
+
+    for _, test := range feedTests {
+        outCh := make(chan crawler.Message)
+        reader := crawler.DocReader{DocId: "DOC_ID", Reader: ioutil.NopCloser(strings.NewReader(test.feed))}
+
+        go scanner.Scan(reader, outCh)
+
+        title := ""
+        var links []string
+        for msg := <- outCh; msg.Type != crawler.EndOfStream; msg = <- outCh {
+            switch msg.Type {
+                case crawler.Title:
+                    title = msg.Content[0]
+                case crawler.Link:
+                    assert.Equal(crawler.Navigation, msg.Kind)
+                    links = append(links, msg.Content...)
+            }
+        }
+
+        assert.Equal(test.expectedTitle, title, "Test '%s' failed", test.desc)
+        assert.Equal(test.expectedLinks, links, "Test '%s' failed", test.desc)
+    }
+}
@@ -4,25 +4,66 @@ import (
     "go.uber.org/zap"
     "golang.org/x/net/html"
     "io/ioutil"
+    "strings"
     "webCrawler/crawler"
+    "webCrawler/cssscanner"
 )
 
-const linksPerMsg = 20
+type Options struct {
+    // Maximum number of links sent in a single message.
+    LinksPerMsg int
 
-type HtmlScanner struct {}
+    // User agent of the crawler, e.g. "webCrawler/1.0". The robots meta
+    // tags named after its product token apply as well as the generic ones.
+    UserAgent string
 
-func New() crawler.Scanner {
-    return &HtmlScanner{}
+    Logger *zap.Logger
 }
 
-func (*HtmlScanner) Scan(r crawler.DocReader, outCh chan crawler.Message) {
-    tokenizer := html.NewTokenizer(r.Reader)
-    logger, _ := zap.NewProduction(
-        zap.Fields(zap.String("DocId", string(r.DocId))),
-    )
+func DefaultOptions() Options {
+    logger, _ := zap.NewProduction()
+
+    return Options {
+        LinksPerMsg: 20,
+        Logger: logger,
+    }
+}
+
+type HtmlScanner struct {
+    options Options
+    // Lower case product token of the user agent, e.g. "webcrawler"
+    botName string
+}
 
-    findTitle(tokenizer, r.DocId, outCh, logger)
-    findLinks(tokenizer, r.DocId, outCh, logger)
+func New(options Options) crawler.Scanner {
+    if options.Logger == nil {
+        options.Logger = zap.NewNop()
+    }
+
+    if options.LinksPerMsg < 1 {
+        options.LinksPerMsg = 1
+    }
+
+    botName := strings.ToLower(strings.TrimSpace(options.UserAgent))
+    if end := strings.IndexAny(botName, "/ "); end >= 0 {
+        botName = botName[:end]
+    }
+
+    return &HtmlScanner{options, botName}
+}
+
+func (s *HtmlScanner) Scan(r crawler.DocReader, outCh chan crawler.Message) {
+    tokenizer := html.NewTokenizer(r.Reader)
+    logger := s.options.Logger.With(zap.String("DocId", string(r.DocId)))
+
+    scan := &docScan{
+        docId: r.DocId,
+        outCh: outCh,
+        links: newLinkBatcher(r.DocId, s.options.LinksPerMsg, outCh, logger),
+        logger: logger,
+        botName: s.botName,
+    }
+    scan.run(tokenizer)
 
     eos := crawler.EndOfStreamMsg(r.DocId)
 
@@ -36,110 +77,265 @@ func (*HtmlScanner) Scan(r crawler.DocReader, outCh chan crawler.Message) {
     r.Reader.Close()
 }
 
-func findTitle(token *html.Tokenizer, docId crawler.DocId, outCh chan crawler.Message, logger *zap.Logger) {
+// State of the scan of a single document.
+type docScan struct {
+    docId  crawler.DocId
+    outCh  chan crawler.Message
+    links  *linkBatcher
+    logger *zap.Logger
+    // Lower case product token of the crawler, empty if unknown
+    botName string
+
+    // Whether the end of the head was reached. Documents without head end
+    // it at their first anchor.
+    headDone       bool
+    titleFound     bool
+    baseFound      bool
+    canonicalFound bool
+    langFound      bool
+
+    // Heading being read, e.g. h1, and its text so far
+    heading     string
+    headingText strings.Builder
+
+    // Number of links found so far
+    linksFound int
+    // Link of the anchor being read, and its text so far
+    anchor     *foundLink
+    anchorText strings.Builder
+}
+
+// Looks for the title, the base URL, the canonical link and the robots
+// directives of the document until the end of its head, the directives of
+// every robots meta tag applying together, and for its links
+// and metadata until the end of the document, even after the end of its
+// body, which malformed documents may have content after.
+func (ds *docScan) run(token *html.Tokenizer) {
 
 loopOverTokens:
     for {
         switch token.Next() {
-            case html.StartTagToken:
-                if tagNameEquals("title", token) {
-                    if tokenType := token.Next(); tokenType == html.TextToken {
-                        title := string(token.Text())
+            case html.StartTagToken, html.SelfClosingTagToken:
+                tagName, hasAttributes := token.TagName()
+                ds.startTag(token, string(tagName), hasAttributes)
+
+            case html.TextToken:
+                if ds.heading != "" || ds.anchor != nil {
+                    text := token.Text()
+                    if ds.heading != "" {
+                        ds.headingText.Write(text)
+                    }
+                    if ds.anchor != nil {
+                        ds.anchorText.Write(text)
+                    }
+                }
+
+            case html.EndTagToken:
+                tagName, _ := token.TagName()
+                ds.endTag(string(tagName))
+
+            case html.ErrorToken:
+                ds.logger.Debug("Reached end of document")
+                break loopOverTokens
+        }
+    }
 
-                        logger.Debug("Found title", zap.String("Title", title))
+    ds.endAnchor()
+    ds.links.flush()
+}
 
-                        msg := crawler.Message{
-                            Content: []string{title},
-                            DocId: docId,
-                            Type: crawler.Title,
-                        }
+func (ds *docScan) startTag(token *html.Tokenizer, tagName string, hasAttributes bool) {
+    // Anchors can't be nested
+    if tagName == "a" {
+        ds.endAnchor()
+    }
 
-                        logger.Debug("Send title", zap.Object("Msg", msg))
+    var attrs map [string] string
+    if hasAttributes {
+        attrs = readAttributes(token)
+    }
 
-                        outCh <- msg
+    // Resources used by inline styles, e.g. background images
+    for _, href := range cssscanner.Urls(attrs["style"]) {
+        ds.addLink(foundLink{href: href, kind: crawler.Resource})
+    }
 
-                        break loopOverTokens
-                    }
+    switch {
+        case tagName == "title" && !ds.headDone && !ds.titleFound:
+            if tokenType := token.Next(); tokenType == html.TextToken {
+                title := string(token.Text())
+
+                ds.logger.Debug("Found title", zap.String("Title", title))
+
+                ds.send(crawler.Title, title)
+
+                ds.titleFound = true
+            }
+
+        case tagName == "style":
+            if tokenType := token.Next(); tokenType == html.TextToken {
+                for _, href := range cssscanner.Urls(string(token.Text())) {
+                    ds.addLink(foundLink{href: href, kind: crawler.Resource})
                 }
+            }
+
+        case tagName == "body":
+            ds.logger.Debug("Reached <body>")
+            ds.headDone = true
+
+        case tagName == "html" && hasAttributes:
+            if lang := strings.TrimSpace(attrs["lang"]); lang != "" && !ds.langFound {
+                ds.sendMetadata("lang", lang)
+                ds.langFound = true
+            }
+
+        case tagName == "base" && hasAttributes && !ds.headDone && !ds.baseFound:
+            if href := attrs["href"]; href != "" {
+                ds.send(crawler.Base, href)
+                ds.baseFound = true
+            }
+
+        case headingTags[tagName]:
+            ds.heading = tagName
+            ds.headingText.Reset()
+
+        case hasAttributes && linkTags[tagName]:
+            if !ds.headDone {
+                ds.headTag(tagName, attrs)
+            }
+
+            for _, link := range tagLinks(tagName, attrs) {
+                if tagName == "a" {
+                    ds.linksFound++
+                    link.attributes.Position = ds.linksFound
+
+                    anchor := link
+                    ds.anchor = &anchor
+                    ds.anchorText.Reset()
+                } else {
+                    ds.addLink(link)
+                }
+            }
 
-            case html.ErrorToken:
-                break loopOverTokens
+            // Images in anchors give them their text
+            if tagName == "img" && ds.anchor != nil {
+                ds.anchorText.WriteString(" " + attrs["alt"] + " ")
+            }
 
-            case html.EndTagToken:
-                if tagNameEquals("head", token) {
-                    logger.Debug("Reached </head>")
-                    break loopOverTokens
+            for _, entry := range metaEntries(tagName, attrs) {
+                if entry.key == "lang" && ds.langFound {
+                    continue
                 }
-        }
-    }
+                ds.sendMetadata(entry.key, entry.value)
+                ds.langFound = ds.langFound || entry.key == "lang"
+            }
+
+            if isJsonLd(tagName, attrs) {
+                if tokenType := token.Next(); tokenType == html.TextToken {
+                    if jsonLd := strings.TrimSpace(string(token.Text())); jsonLd != "" {
+                        ds.sendMetadata("json-ld", jsonLd)
+                    }
+                }
+            }
 
+            if tagName == "a" && !ds.headDone {
+                ds.logger.Debug("Reached anchor without <body>")
+                ds.headDone = true
+            }
+    }
 }
 
-func findLinks(token *html.Tokenizer, docId crawler.DocId, linksCh chan crawler.Message, logger *zap.Logger) {
+// Adds a link found, which is not an anchor, at the next position.
+func (ds *docScan) addLink(link foundLink) {
+    ds.linksFound++
+    link.attributes.Position = ds.linksFound
 
-    unsentLinks := make([]string, 0, linksPerMsg)
+    ds.links.add(link)
+}
 
-loopOverTokens:
-    for {
-        switch token.Next() {
-            case html.StartTagToken:
-                if tagName, hasAttributes := token.TagName();
-                    areEqual(tagName,"a") && hasAttributes {
+func (ds *docScan) endTag(tagName string) {
+    switch {
+        case tagName == "a":
+            ds.endAnchor()
 
-                loopOverAttributes:
-                    for hasMoreAttr := true; hasMoreAttr; {
-                        var key, val []byte
-                        key, val, hasMoreAttr = token.TagAttr()
+        case tagName == "head" && !ds.headDone:
+            ds.logger.Debug("Reached </head>")
+            ds.headDone = true
 
-                        if areEqual(key, "href") {
-                            link := string(val)
+        case tagName == ds.heading:
+            if text := strings.Join(strings.Fields(ds.headingText.String()), " "); text != "" {
+                ds.sendMetadata(ds.heading, text)
+            }
+            ds.heading = ""
+    }
+}
 
-                            logger.Debug("Found link", zap.String("Link", link))
+// Adds the link of the anchor being read, if any, once its text is known.
+func (ds *docScan) endAnchor() {
+    if ds.anchor == nil {
+        return
+    }
 
-                            if len(unsentLinks) < linksPerMsg {
-                                unsentLinks = append(unsentLinks, link)
-                            }
+    ds.anchor.attributes.Text = strings.Join(strings.Fields(ds.anchorText.String()), " ")
+    ds.links.add(*ds.anchor)
 
-                            if len(unsentLinks) == linksPerMsg {
-                                msg := crawler.Message{
-                                    Content: unsentLinks,
-                                    DocId:   docId,
-                                    Type:    crawler.Link,
-                                }
+    ds.anchor = nil
+}
 
-                                logger.Debug("Send links", zap.Object("Msg", msg))
-                                linksCh <- msg
+// Handles the tags only taken into account in the head of the document.
+func (ds *docScan) headTag(tagName string, attrs map [string] string) {
+    if href, isCanonical := canonicalHref(tagName, attrs); isCanonical && !ds.canonicalFound {
+        ds.send(crawler.Canonical, href)
+        ds.canonicalFound = true
+    }
 
-                                unsentLinks = make([]string, 0, linksPerMsg)
-                            }
+    if directives, isRobots := robotsDirectives(tagName, attrs, ds.botName); isRobots {
+        ds.send(crawler.Robots, directives...)
+    }
+}
 
-                            break loopOverAttributes
-                        }
-                    }
-                }
+func (ds *docScan) send(msgType crawler.MessageType, content ...string) {
+    msg := crawler.Message{
+        Content: content,
+        DocId: ds.docId,
+        Type: msgType,
+    }
 
-            case html.ErrorToken:
-                break loopOverTokens
+    ds.logger.Debug("Send " + msgType.String(), zap.Object("Msg", msg))
 
-            case html.EndTagToken:
-                if tagNameEquals("body", token) {
-                    logger.Debug("Reached </body>")
-                    break loopOverTokens
-                }
-        }
+    ds.outCh <- msg
+}
+
+func (ds *docScan) sendMetadata(key string, value string) {
+    ds.send(crawler.Metadata, key, value)
+}
 
+// Gets the 'href' of a 'link' tag when its 'rel' is canonical.
+func canonicalHref(tagName string, attrs map [string] string) (href string, isCanonical bool) {
+    if tagName != "link" || !hasValue(attributeValues(attrs, "rel"), "canonical") {
+        return "", false
     }
 
-    if len(unsentLinks) != 0 {
+    return attrs["href"], attrs["href"] != ""
+}
 
-        msg := crawler.Message{
-            Content: unsentLinks,
-            DocId:   docId,
-            Type:    crawler.Link,
-        }
+// Gets the lower case directives in the 'content' of a 'meta' tag when its
+// 'name' is robots or 'botName', e.g. noindex or nofollow.
+func robotsDirectives(tagName string, attrs map [string] string, botName string) (directives []string, isRobots bool) {
+    if tagName != "meta" {
+        return nil, false
+    }
 
-        logger.Debug("Send links", zap.Object("Msg", msg))
+    name := strings.ToLower(strings.TrimSpace(attrs["name"]))
+    if name != "robots" && (botName == "" || name != botName) {
+        return nil, false
+    }
 
-        linksCh <- msg
+    for _, directive := range strings.Split(strings.ToLower(attrs["content"]), ",") {
+        if directive = strings.TrimSpace(directive); directive != "" {
+            directives = append(directives, directive)
+        }
     }
+
+    return directives, len(directives) > 0
 }
@@ -16,6 +16,7 @@ type docscanTest struct {
     html string	// The HTML to crawl.
     expectedTitle string // Expected title
     expectedLinks []string // Expected links
+    expectedCanonical string // Expected canonical link
 }
 
 type docscanTestSuite struct {
@@ -29,24 +30,28 @@ var titleTests = docscanTestSuite{"Tests for titles", []docscanTest{
         "<head><title>This is my title</title></head>",
         "This is my title",
         nil,
+        "",
     },
     {
         "valid title with special chars",
         "<head><title>This is my title with some special chars %^&#</title></head>",
         "This is my title with some special chars %^&#",
         nil,
+        "",
     },
     {
         "title inside a comment",
         "<head><!-- <title>This is not the title</title> --></head>",
         "",
         nil,
+        "",
     },
     {
         "should stop scanning after end of head",
         "<head></head><title>This is not the title</title>",
         "",
         nil,
+        "",
     },
     {
         "valid title in multiline multiline",
@@ -55,12 +60,14 @@ var titleTests = docscanTestSuite{"Tests for titles", []docscanTest{
                 </head>`,
         "This is my title",
         nil,
+        "",
     },
     {
         "empty title",
         "<head><title></title></head>",
         "",
         nil,
+        "",
     },
 }}
 
@@ -77,6 +84,7 @@ var linksTests = docscanTestSuite{"Tests for links", []docscanTest{
             </body>`,
         "",
         []string{"/resource1", "/resource2?query#pos"},
+        "",
     },
     {
         "multiple valid links",
@@ -126,6 +134,7 @@ var linksTests = docscanTestSuite{"Tests for links", []docscanTest{
             "/r11", "/r12", "/r13", "/r14", "/r15", "/r16", "/r17", "/r18", "/r19", "/r20",
             "/r21", "/r22", "/r23", "/r24", "/r25", "/r26", "/r27", "/r28", "/r29", "/r30",
             "/r31", "/r32", "/r33", "/r34", "/r35", "/r36", "/r37"},
+        "",
     },
     {
         "href in different tag than anchor",
@@ -133,6 +142,7 @@ var linksTests = docscanTestSuite{"Tests for links", []docscanTest{
                <body><div href="/resource1"></div></body>`,
         "",
         nil,
+        "",
     },
     {
         "link after body",
@@ -141,6 +151,7 @@ var linksTests = docscanTestSuite{"Tests for links", []docscanTest{
                <a href="/resource1></a>"`,
         "",
         nil,
+        "",
     },
     {
         "link inside comment",
@@ -148,6 +159,37 @@ var linksTests = docscanTestSuite{"Tests for links", []docscanTest{
                <body><!-- <a href="/resource1">Link to resource 1</a> --></body>`,
         "",
         nil,
+        "",
+    },
+}}
+
+var canonicalTests = docscanTestSuite{"Tests for canonical links", []docscanTest{
+    {
+        "canonical link after title",
+        `<head>
+                <title>Page 2</title>
+                <link rel="stylesheet" href="/style.css">
+                <link rel="Canonical" href="http://example.com/page"/>
+            </head>
+            <body><a href="/other">Other</a></body>`,
+        "Page 2",
+        []string{"/other"},
+        "http://example.com/page",
+    },
+    {
+        "canonical link outside head",
+        `<head></head>
+            <body><link rel="canonical" href="/page"></body>`,
+        "",
+        nil,
+        "",
+    },
+    {
+        "document without head",
+        `<title>No head</title><a href="/first">First</a><a href="/second">Second</a>`,
+        "No head",
+        []string{"/first", "/second"},
+        "",
     },
 }}
 
@@ -157,12 +199,14 @@ var linksAndTitle = docscanTestSuite{"Tests for links and title", []docscanTest{
         "",
         "",
         nil,
+        "",
     },
     {
         "not html",
         "lorem ipsu",
         "",
         nil,
+        "",
     },
     {
         "valid links and title",
@@ -178,6 +222,7 @@ var linksAndTitle = docscanTestSuite{"Tests for links and title", []docscanTest{
             </body>`,
         "This is my title",
         []string{"/resource1", "/resource2?query#pos"},
+        "",
     },
     {
         "not valid title, valid links",
@@ -194,18 +239,19 @@ var linksAndTitle = docscanTestSuite{"Tests for links and title", []docscanTest{
             </body>`,
         "",
         []string{"/resource1", "/resource2?query#pos"},
+        "",
     },
 }}
 
 func TestHtmlScanner_Scan(t *testing.T) {
     assert := assert.New(t)
 
-    testSuites := []docscanTestSuite{titleTests, linksTests, linksAndTitle}
+    testSuites := []docscanTestSuite{titleTests, linksTests, canonicalTests, linksAndTitle}
 
     numTestsRan := 0
     numTestSuitesRan := 0
 
-    scanner := New()
+    scanner := New(DefaultOptions())
 
     for _, testSuite := range testSuites {
 
@@ -215,12 +261,13 @@ func TestHtmlScanner_Scan(t *testing.T) {
 
             scanOutputCh := make(chan crawler.Message)
 
-            docReader := crawler.DocReader{docId, ioutil.NopCloser(strings.NewReader(test.html)) }
+            docReader := crawler.DocReader{DocId: docId, Reader: ioutil.NopCloser(strings.NewReader(test.html)) }
 
             go scanner.Scan(docReader, scanOutputCh)
 
             var actualLinks []string
             actualTitle := ""
+            actualCanonical := ""
 
         loopOverMessages:
             for {
@@ -235,13 +282,22 @@ func TestHtmlScanner_Scan(t *testing.T) {
                         actualTitle = msg.Content[0]
 
                     case crawler.Link:
-                        actualLinks = append(actualLinks, msg.Content...)
+                        if msg.Kind == crawler.Navigation {
+                            actualLinks = append(actualLinks, msg.Content...)
+                        }
+
+                    case crawler.Canonical:
+                        actualCanonical = msg.Content[0]
 
                     case crawler.EndOfStream:
                         break loopOverMessages
                 }
             }
 
+            assert.Equal(test.expectedCanonical, actualCanonical,
+                "Test '%s' of test suite '%s' failed. Expected canonical link '%s' but got '%s'",
+                test.desc, testSuite.desc, test.expectedCanonical, actualCanonical)
+
             assert.Equal(actualTitle, test.expectedTitle,
                 "Test '%s' of test suite '%s' failed. Expected title '%s' but got '%s'",
                 test.desc, testSuite.desc, test.expectedTitle, actualTitle)
@@ -261,13 +317,254 @@ func TestHtmlScanner_Scan(t *testing.T) {
     fmt.Printf("Ran %d tests from %d test suites\n", numTestsRan, numTestSuitesRan)
 }
 
+// Scans 'html' and gets the messages sent before the end of the stream.
+func scanMessages(html string) []crawler.Message {
+    return scanMessagesWith(DefaultOptions(), html)
+}
+
+func scanMessagesWith(options Options, html string) []crawler.Message {
+    scanOutputCh := make(chan crawler.Message)
+    docReader := crawler.DocReader{DocId: "DOC_ID", Reader: ioutil.NopCloser(strings.NewReader(html))}
+
+    go New(options).Scan(docReader, scanOutputCh)
+
+    var msgs []crawler.Message
+    for msg := <- scanOutputCh; msg.Type != crawler.EndOfStream; msg = <- scanOutputCh {
+        msgs = append(msgs, msg)
+    }
+
+    return msgs
+}
+
+// Gets the contents of the messages of 'msgType'.
+func contentsOf(msgs []crawler.Message, msgType crawler.MessageType) []string {
+    var contents []string
+    for _, msg := range msgs {
+        if msg.Type == msgType {
+            contents = append(contents, msg.Content...)
+        }
+    }
+
+    return contents
+}
+
+func TestHtmlScanner_ScanRobotsDirectives(t *testing.T) {
+    assert := assert.New(t)
+
+    msgs := scanMessages(`
+        <head>
+            <meta name="description" content="Not robots">
+            <meta name="ROBOTS" content="NoIndex, nofollow">
+        </head>
+        <body>
+            <a href="/followed">Followed</a>
+            <a rel="external nofollow" href="/not-followed">Not followed</a>
+        </body>`)
+
+    assert.Equal([]string{"noindex", "nofollow"}, contentsOf(msgs, crawler.Robots))
+    assert.Equal([]string{"/followed"}, contentsOf(msgs, crawler.Link))
+    assert.Equal([]string{"/not-followed"}, contentsOf(msgs, crawler.NofollowLink))
+
+    msgs = scanMessages(`<head></head><body><meta name="robots" content="noindex"></body>`)
+
+    assert.Empty(contentsOf(msgs, crawler.Robots), "Expected robots directives only in the head")
+}
+
+func TestHtmlScanner_CombineRobotsDirectives(t *testing.T) {
+    assert := assert.New(t)
+
+    html := `
+        <head>
+            <meta name="robots" content="noarchive">
+            <meta name="otherbot" content="noindex">
+            <meta name="WebCrawler" content="nofollow">
+            <meta name="robots" content="nosnippet">
+        </head>`
+
+    options := DefaultOptions()
+    options.UserAgent = "webCrawler/1.0"
+
+    assert.Equal([]string{"noarchive", "nofollow", "nosnippet"}, contentsOf(scanMessagesWith(options, html), crawler.Robots))
+    assert.Equal([]string{"noarchive", "nosnippet"}, contentsOf(scanMessages(html), crawler.Robots))
+}
+
+func TestHtmlScanner_ScanBase(t *testing.T) {
+    assert := assert.New(t)
+
+    msgs := scanMessages(`
+        <head>
+            <base target="_blank">
+            <base href="/docs/">
+            <base href="/other/">
+        </head>
+        <body><a href="page">Page</a></body>`)
+
+    assert.Equal(crawler.Base, msgs[0].Type, "Expected the base URL before the links")
+    assert.Equal([]string{"/docs/"}, contentsOf(msgs, crawler.Base))
+    assert.Equal([]string{"page"}, contentsOf(msgs, crawler.Link))
+}
+
+func TestHtmlScanner_ScanMetadata(t *testing.T) {
+    assert := assert.New(t)
+
+    msgs := scanMessages(`
+        <html lang="en">
+        <head>
+            <title>Title</title>
+            <meta name="Description" content="About this page">
+            <meta name="keywords" content="go, crawler">
+            <meta property="og:title" content="Open graph title">
+            <meta name="twitter:card" content="summary">
+            <link rel="alternate" hreflang="es" href="/es/page">
+            <script type="application/ld+json">{"@type": "Article"}</script>
+        </head>
+        <body>
+            <h1>Main <em>heading</em></h1>
+            <h2><a href="/section">Section</a></h2>
+            <h4>Not metadata</h4>
+        </body>
+        </html>`)
+
+    metadata := make(map [string] []string)
+    for _, msg := range msgs {
+        if msg.Type == crawler.Metadata {
+            metadata[msg.Content[0]] = append(metadata[msg.Content[0]], msg.Content[1])
+        }
+    }
+
+    assert.Equal(map [string] []string{
+        "lang": {"en"},
+        "description": {"About this page"},
+        "keywords": {"go, crawler"},
+        "og:title": {"Open graph title"},
+        "twitter:card": {"summary"},
+        "hreflang:es": {"/es/page"},
+        "json-ld": {`{"@type": "Article"}`},
+        "h1": {"Main heading"},
+        "h2": {"Section"},
+    }, metadata)
+    assert.Equal([]string{"/es/page", "/section"}, contentsOf(msgs, crawler.Link))
+}
+
+func TestHtmlScanner_ScanLinkAttributes(t *testing.T) {
+    assert := assert.New(t)
+
+    msgs := scanMessages(`
+        <head><link rel="stylesheet" href="/style.css"></head>
+        <body>
+            <a href="/first" title=" First page " rel="Next">The <b>first</b>
+                page</a>
+            <a href="/second"><img src="/logo.png" alt="Logo"></a>
+            <a href="/third">Unclosed <a href="/fourth">Fourth</a>
+        </body>`)
+
+    var attributes []crawler.LinkAttributes
+    for _, msg := range msgs {
+        if msg.Type == crawler.Link && msg.Kind == crawler.Navigation {
+            assert.Equal(len(msg.Content), len(msg.Attributes))
+            attributes = append(attributes, msg.Attributes...)
+        }
+    }
+
+    assert.Equal([]crawler.LinkAttributes{
+        {Text: "The first page", Title: "First page", Rel: []string{"next"}, Position: 2},
+        {Text: "Logo", Position: 3},
+        {Text: "Unclosed", Position: 5},
+        {Text: "Fourth", Position: 6},
+    }, attributes)
+}
+
+// Gets the contents of the link messages of 'msgType' with links of 'kind'.
+func linksOf(msgs []crawler.Message, msgType crawler.MessageType, kind crawler.LinkKind) []string {
+    var links []string
+    for _, msg := range msgs {
+        if msg.Type == msgType && msg.Kind == kind {
+            links = append(links, msg.Content...)
+        }
+    }
+
+    return links
+}
+
+func TestHtmlScanner_ScanLinkKinds(t *testing.T) {
+    assert := assert.New(t)
+
+    msgs := scanMessages(`
+        <head>
+            <meta http-equiv="refresh" content="5; URL='/refreshed'">
+            <link rel="stylesheet" href="/style.css">
+            <link rel="preconnect" href="https://cdn.example.com">
+            <link rel="next" href="/page/2">
+            <link rel="alternate" type="application/rss+xml" title="Feed" href="/feed.xml">
+            <script src="/app.js"></script>
+        </head>
+        <body>
+            <img src="/small.png" srcset="/medium.png 2x, /large.png 3x">
+            <map><area href="/area" alt="Area"></map>
+            <iframe src="/frame"></iframe>
+            <form action="/search"><input name="q"></form>
+            <form><input name="self"></form>
+        </body>
+        </html>
+        <a href="/after-body">After body</a>`)
+
+    assert.Equal([]string{"/refreshed", "/page/2", "/feed.xml", "/area", "/frame", "/after-body"},
+        linksOf(msgs, crawler.Link, crawler.Navigation))
+    assert.Equal([]string{"/style.css", "/app.js", "/small.png", "/medium.png", "/large.png"},
+        linksOf(msgs, crawler.Link, crawler.Resource))
+    assert.Equal([]string{"/search"}, linksOf(msgs, crawler.Link, crawler.Form))
+}
+
+func TestSrcsetUrls(t *testing.T) {
+    tests := []struct {
+        desc   string   // A short description of the test case.
+        srcset string   // Value of the srcset attribute.
+        urls   []string // Expected URLs of the candidates.
+    }{
+        {"no candidate", " , ", nil},
+        {"descriptors", "/small.jpg 480w, /large.jpg 1080w", []string{"/small.jpg", "/large.jpg"}},
+        {"no descriptors", "/a.jpg, /b.jpg,, /c.jpg,", []string{"/a.jpg", "/b.jpg", "/c.jpg"}},
+        {
+            "commas in URLs",
+            "https://cdn.example.com/w_100,h_50/x.jpg 1x,\n\thttps://cdn.example.com/w_200,h_100/x.jpg 2x",
+            []string{"https://cdn.example.com/w_100,h_50/x.jpg", "https://cdn.example.com/w_200,h_100/x.jpg"},
+        },
+        {"commas in descriptors", "/a.jpg (1, 2) 1x, /b.jpg", []string{"/a.jpg", "/b.jpg"}},
+    }
+
+    for _, test := range tests {
+        assert.Equal(t, test.urls, srcsetUrls(test.srcset), "Test '%s' failed", test.desc)
+    }
+}
+
+func TestHtmlScanner_ScanStyles(t *testing.T) {
+    assert := assert.New(t)
+
+    msgs := scanMessages(`
+        <head>
+            <style>
+                @import "/theme.css";
+                body { background: url(/bg.png); }
+            </style>
+        </head>
+        <body>
+            <div style="background-image: url('/hero.jpg')">
+                <a href="/page" style="background: url(/icon.svg)">Page</a>
+            </div>
+            <p style="color: red; background: url(data:image/png;base64,AAAA)">Text</p>
+        </body>`)
+
+    assert.Equal([]string{"/theme.css", "/bg.png", "/hero.jpg", "/icon.svg"},
+        linksOf(msgs, crawler.Link, crawler.Resource))
+    assert.Equal([]string{"/page"}, linksOf(msgs, crawler.Link, crawler.Navigation))
+}
 
 func benchmarkHtmlScanner_Scan(fileName string, b *testing.B) {
 
     var numLinks = 0
     var title string
 
-    scanner := New()
+    scanner := New(DefaultOptions())
 
     scanOutputCh := make(chan crawler.Message)
 
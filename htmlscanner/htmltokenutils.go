@@ -1,24 +1,44 @@
 package htmlscanner
 
-import "golang.org/x/net/html"
+import (
+    "golang.org/x/net/html"
+    "strings"
+)
 
-// Compare a byte array and a string without the extra
-// copy needed to convert a byte array into a string.
-func areEqual(b []byte, s string) bool {
-    if len(b) != len(s) {
-        return false
-    }
+// Reads the attributes of the current tag, by name. Only the first value
+// of a repeated attribute is kept.
+func readAttributes(token *html.Tokenizer) map [string] string {
+    attrs := make(map [string] string)
+
+    for hasMoreAttr := true; hasMoreAttr; {
+        var key, val []byte
+        key, val, hasMoreAttr = token.TagAttr()
 
-    for i, byte := range b {
-        if byte != s[i] {
-            return false
+        if _, isRepeated := attrs[string(key)]; !isRepeated {
+            attrs[string(key)] = string(val)
         }
     }
 
-    return true
+    return attrs
 }
 
-func tagNameEquals(s string, token *html.Tokenizer) bool {
-    tagName, _ := token.TagName()
-    return areEqual(tagName, s)
+// Gets the lower case values of a space separated attribute, e.g. 'rel'.
+// Nil when it has none.
+func attributeValues(attrs map [string] string, name string) []string {
+    values := strings.Fields(strings.ToLower(attrs[name]))
+    if len(values) == 0 {
+        return nil
+    }
+
+    return values
+}
+
+func hasValue(values []string, value string) bool {
+    for _, v := range values {
+        if v == value {
+            return true
+        }
+    }
+
+    return false
 }
@@ -0,0 +1,252 @@
+package htmlscanner
+
+import (
+    "go.uber.org/zap"
+    "strings"
+    "webCrawler/crawler"
+)
+
+// Link found in a document.
+type foundLink struct {
+    href       string
+    kind       crawler.LinkKind
+    nofollow   bool
+    attributes crawler.LinkAttributes
+}
+
+// Values of 'rel' of the 'link' tags pointing to resources of the document.
+var resourceRels = []string{
+    "stylesheet", "icon", "apple-touch-icon", "mask-icon", "manifest",
+    "preload", "prefetch", "modulepreload",
+}
+
+// Values of 'rel' of the 'link' tags not pointing to a document.
+var ignoredRels = []string{"canonical", "preconnect", "dns-prefetch"}
+
+// Tags with links, by tag name.
+var linkTags = map [string] bool {
+    "a": true, "area": true, "link": true, "iframe": true, "frame": true,
+    "img": true, "script": true, "form": true, "meta": true,
+}
+
+// Gets the links of a tag from its attributes. The text of anchors is
+// not known yet.
+func tagLinks(tagName string, attrs map [string] string) []foundLink {
+    rels := attributeValues(attrs, "rel")
+    nofollow := hasValue(rels, "nofollow")
+
+    var links []foundLink
+    add := func(href string, kind crawler.LinkKind, nofollow bool) {
+        if href = strings.TrimSpace(href); href != "" {
+            links = append(links, foundLink{href, kind, nofollow, linkAttributes(attrs, rels)})
+        }
+    }
+
+    switch tagName {
+        case "a", "area":
+            if href, hasHref := attrs["href"]; hasHref {
+                links = append(links, foundLink{href, crawler.Navigation, nofollow, linkAttributes(attrs, rels)})
+            }
+
+        case "link":
+            // Links to other documents, e.g. alternate ones like feeds,
+            // are followed like anchors
+            kind := crawler.Navigation
+            for _, rel := range rels {
+                if hasValue(ignoredRels, rel) {
+                    return nil
+                }
+                if hasValue(resourceRels, rel) {
+                    kind = crawler.Resource
+                }
+            }
+            add(attrs["href"], kind, nofollow)
+
+        case "iframe", "frame":
+            add(attrs["src"], crawler.Navigation, false)
+
+        case "img":
+            add(attrs["src"], crawler.Resource, false)
+            for _, href := range srcsetUrls(attrs["srcset"]) {
+                add(href, crawler.Resource, false)
+            }
+
+        case "script":
+            add(attrs["src"], crawler.Resource, false)
+
+        case "form":
+            add(attrs["action"], crawler.Form, false)
+
+        case "meta":
+            if strings.EqualFold(strings.TrimSpace(attrs["http-equiv"]), "refresh") {
+                add(refreshUrl(attrs["content"]), crawler.Navigation, false)
+            }
+    }
+
+    return links
+}
+
+// Gets the attributes of a link known from its tag: its title, its 'rel'
+// values, and the alternative text of images and image map areas.
+func linkAttributes(attrs map [string] string, rels []string) crawler.LinkAttributes {
+    return crawler.LinkAttributes{
+        Text: strings.Join(strings.Fields(attrs["alt"]), " "),
+        Title: strings.TrimSpace(attrs["title"]),
+        Rel: rels,
+    }
+}
+
+// Gets the URLs of the image candidates of a 'srcset' attribute, e.g.
+// "small.jpg 480w, large.jpg 1080w". As in the HTML specification, a URL
+// ends at the first whitespace, so that it may contain commas, and its
+// descriptors end at the next comma outside parentheses.
+func srcsetUrls(srcset string) []string {
+    var urls []string
+
+    for pos := 0; pos < len(srcset); {
+        // Skip the separators before the candidate
+        for pos < len(srcset) && (isHtmlSpace(srcset[pos]) || srcset[pos] == ',') {
+            pos++
+        }
+
+        start := pos
+        for pos < len(srcset) && !isHtmlSpace(srcset[pos]) {
+            pos++
+        }
+        href := srcset[start:pos]
+
+        // Trailing commas end a candidate without descriptors
+        if trimmed := strings.TrimRight(href, ","); trimmed != href {
+            href = trimmed
+        } else {
+            depth := 0
+
+        skipDescriptors:
+            for ; pos < len(srcset); pos++ {
+                switch srcset[pos] {
+                    case '(':
+                        depth++
+                    case ')':
+                        if depth > 0 {
+                            depth--
+                        }
+                    case ',':
+                        if depth == 0 {
+                            pos++
+                            break skipDescriptors
+                        }
+                }
+            }
+        }
+
+        if href != "" {
+            urls = append(urls, href)
+        }
+    }
+
+    return urls
+}
+
+func isHtmlSpace(c byte) bool {
+    return c == ' ' || c == '\t' || c == '\n' || c == '\f' || c == '\r'
+}
+
+// Gets the URL of the 'content' of a refresh 'meta' tag, e.g.
+// "5; url=/other", or empty if it just reloads the document.
+func refreshUrl(content string) string {
+    separator := strings.IndexAny(content, ";,")
+    if separator < 0 {
+        return ""
+    }
+
+    target := strings.TrimSpace(content[separator+1:])
+    if len(target) >= 4 && strings.EqualFold(target[:3], "url") {
+        if rest := strings.TrimSpace(target[3:]); strings.HasPrefix(rest, "=") {
+            target = strings.TrimSpace(rest[1:])
+        }
+    }
+
+    return strings.Trim(target, `"'`)
+}
+
+// Kind of the messages the links are sent in.
+type linkBatch struct {
+    msgType crawler.MessageType
+    kind    crawler.LinkKind
+}
+
+// Groups the found links into messages of up to 'linksPerMsg' links of the
+// same kind.
+type linkBatcher struct {
+    docId       crawler.DocId
+    linksPerMsg int
+    unsent      map [linkBatch] []string
+    attributes  map [linkBatch] []crawler.LinkAttributes
+    // Batches with unsent links, in the order they got their first link
+    batches     []linkBatch
+    outCh       chan crawler.Message
+    logger      *zap.Logger
+}
+
+func newLinkBatcher(docId crawler.DocId, linksPerMsg int, outCh chan crawler.Message, logger *zap.Logger) *linkBatcher {
+    return &linkBatcher{
+        docId,
+        linksPerMsg,
+        make(map [linkBatch] []string),
+        make(map [linkBatch] []crawler.LinkAttributes),
+        nil,
+        outCh,
+        logger,
+    }
+}
+
+func (lb *linkBatcher) add(link foundLink) {
+    lb.logger.Debug("Found link",
+        zap.String("Link", link.href),
+        zap.Stringer("Kind", link.kind),
+        zap.Bool("Nofollow", link.nofollow))
+
+    batch := linkBatch{crawler.Link, link.kind}
+    if link.nofollow {
+        batch.msgType = crawler.NofollowLink
+    }
+
+    if _, hasLinks := lb.unsent[batch]; !hasLinks {
+        lb.batches = append(lb.batches, batch)
+    }
+    lb.unsent[batch] = append(lb.unsent[batch], link.href)
+    lb.attributes[batch] = append(lb.attributes[batch], link.attributes)
+
+    if len(lb.unsent[batch]) == lb.linksPerMsg {
+        lb.send(batch)
+    }
+}
+
+func (lb *linkBatcher) send(batch linkBatch) {
+    msg := crawler.Message{
+        Content: lb.unsent[batch],
+        DocId:   lb.docId,
+        Type:    batch.msgType,
+        Kind:    batch.kind,
+        Attributes: lb.attributes[batch],
+    }
+
+    lb.logger.Debug("Send links", zap.Object("Msg", msg))
+    lb.outCh <- msg
+
+    delete(lb.unsent, batch)
+    delete(lb.attributes, batch)
+    for i := range lb.batches {
+        if lb.batches[i] == batch {
+            lb.batches = append(lb.batches[:i], lb.batches[i+1:]...)
+            break
+        }
+    }
+}
+
+// Sends the links not sent yet.
+func (lb *linkBatcher) flush() {
+    for len(lb.batches) > 0 {
+        lb.send(lb.batches[0])
+    }
+}
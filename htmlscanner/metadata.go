@@ -0,0 +1,56 @@
+package htmlscanner
+
+import "strings"
+
+// Metadata entry of a document, e.g. its description.
+type metaEntry struct {
+    key   string
+    value string
+}
+
+// Headings whose text is part of the metadata, by tag name.
+var headingTags = map [string] bool {"h1": true, "h2": true, "h3": true}
+
+// Gets the metadata entries of a tag from its attributes: the description,
+// the keywords and the language of the document, its OpenGraph and Twitter
+// card fields, and its alternate documents in other languages, with keys
+// like 'hreflang:es'.
+func metaEntries(tagName string, attrs map [string] string) []metaEntry {
+    switch tagName {
+        case "meta":
+            content := strings.TrimSpace(attrs["content"])
+            if content == "" {
+                return nil
+            }
+
+            name := strings.ToLower(strings.TrimSpace(attrs["name"]))
+            if name == "" {
+                // OpenGraph fields are meant to be properties
+                name = strings.ToLower(strings.TrimSpace(attrs["property"]))
+            }
+
+            switch {
+                case name == "description" || name == "keywords":
+                    return []metaEntry{{name, content}}
+                case strings.HasPrefix(name, "og:") || strings.HasPrefix(name, "twitter:"):
+                    return []metaEntry{{name, content}}
+                case strings.EqualFold(strings.TrimSpace(attrs["http-equiv"]), "content-language"):
+                    return []metaEntry{{"lang", content}}
+            }
+
+        case "link":
+            lang := strings.ToLower(strings.TrimSpace(attrs["hreflang"]))
+            href := strings.TrimSpace(attrs["href"])
+
+            if lang != "" && href != "" && hasValue(attributeValues(attrs, "rel"), "alternate") {
+                return []metaEntry{{"hreflang:" + lang, href}}
+            }
+    }
+
+    return nil
+}
+
+// Whether a tag is a script with JSON-LD structured data.
+func isJsonLd(tagName string, attrs map [string] string) bool {
+    return tagName == "script" && strings.EqualFold(strings.TrimSpace(attrs["type"]), "application/ld+json")
+}
@@ -1,19 +1,77 @@
 package main
 
 import (
+    "context"
+    "errors"
+    "flag"
     "fmt"
     "os"
+    "os/signal"
     "webCrawler/sitemap"
 )
 
 func main() {
-    startingPoint := os.Args[1]
+    conf, err := parseArgs(os.Args[0], os.Args[1:], os.Stderr)
+    if err == flag.ErrHelp {
+        os.Exit(0)
+    } else if err != nil {
+        os.Exit(2)
+    }
 
-    sm := sitemap.NewSiteMap()
-    err := sm.ProduceFrom(startingPoint)
+    sm, err := sitemap.NewSiteMap(conf.options)
     if err != nil {
-        fmt.Printf("Could not produce site map: " + err.Error())
+        fmt.Fprintf(os.Stderr, "Could not create site map: %s\n", err.Error())
+        os.Exit(1)
+    }
+
+    // The crawl stops on Ctrl-C or when the crawl timeout expires, and
+    // the pages crawled until then are printed.
+    ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+    defer stop()
+
+    if conf.crawlTimeout > 0 {
+        var cancel context.CancelFunc
+        ctx, cancel = context.WithTimeout(ctx, conf.crawlTimeout)
+        defer cancel()
+    }
+
+    err = sm.ProduceFromContext(ctx, conf.startingPoints...)
+
+    // Ctrl-C interrupts the program again once the crawl is stopped
+    stop()
+
+    if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+        fmt.Fprintf(os.Stderr, "Crawl stopped before completion: %s\n", err.Error())
+    } else if err != nil {
+        fmt.Fprintf(os.Stderr, "Could not produce site map: %s\n", err.Error())
+        os.Exit(1)
+    }
+
+    if conf.xmlSitemap.Dir != "" {
+        if _, err := sm.WriteXmlSitemaps(conf.xmlSitemap); err != nil {
+            fmt.Fprintf(os.Stderr, "Could not write XML sitemap: %s\n", err.Error())
+            os.Exit(1)
+        }
+    }
+
+    out := os.Stdout
+    if conf.output != "" {
+        out, err = os.Create(conf.output)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, "Could not create output file: %s\n", err.Error())
+            os.Exit(1)
+        }
+        defer out.Close()
+    }
+
+    if conf.brokenLinks {
+        err = sm.WriteBrokenLinks(out)
     } else {
-        sm.Print()
+        err = sm.Write(conf.format, out)
+    }
+
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "Could not write output: %s\n", err.Error())
+        os.Exit(1)
     }
 }
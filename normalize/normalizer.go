@@ -0,0 +1,297 @@
+package normalize
+
+import (
+    "errors"
+    "net/url"
+    "path"
+    "strings"
+)
+
+// Step of a URL normalization, changing the URL in place.
+type Step interface {
+    Apply(u *url.URL)
+}
+
+type stepFuncImpl struct {
+    apply func(u *url.URL)
+}
+
+func (sfi stepFuncImpl) Apply(u *url.URL) {
+    sfi.apply(u)
+}
+
+func StepFunc(apply func(u *url.URL)) Step {
+    return stepFuncImpl{apply}
+}
+
+// Sequence of steps turning the different URLs of the same document into
+// the same URL. The fragment and the user information are always removed.
+type Normalizer struct {
+    steps []Step
+}
+
+// Creates a normalizer applying 'steps' in the given order.
+func New(steps ...Step) Normalizer {
+    return Normalizer{steps}
+}
+
+// Normalizer removing the query string and the trailing slashes.
+func Default() Normalizer {
+    normalizer, _ := FromNames(DefaultStepNames(), nil, nil)
+    return normalizer
+}
+
+// Gets a normalized copy of 'u'.
+func (n Normalizer) Normalize(u *url.URL) *url.URL {
+    normalized := *u
+
+    normalized.User = nil
+    normalized.Fragment = ""
+    normalized.RawFragment = ""
+
+    for _, step := range n.steps {
+        step.Apply(&normalized)
+    }
+
+    // Do not show '?' character on empty queries
+    normalized.ForceQuery = false
+
+    return &normalized
+}
+
+// Names of the steps selectable by name, in the order they are applied.
+// The unreserved characters are decoded before removing the dot segments,
+// as in RFC 3986, section 6.2.2, so that e.g. "%2E%2E" is removed as well.
+var stepNames = []string{
+    "strip-query",
+    "sort-query",
+    "lowercase-host",
+    "default-port",
+    "encoding",
+    "dot-segments",
+    "index",
+    "trim-slash",
+}
+
+var namedSteps = map [string] Step {
+    "strip-query": StripQuery,
+    "sort-query": SortQuery,
+    "lowercase-host": LowercaseHost,
+    "default-port": RemoveDefaultPort,
+    "dot-segments": ResolveDotSegments,
+    "index": CollapseIndex,
+    "trim-slash": TrimTrailingSlash,
+    "encoding": NormalizeEncoding,
+}
+
+func StepNames() []string {
+    return append([]string{}, stepNames...)
+}
+
+func DefaultStepNames() []string {
+    return []string{"strip-query", "trim-slash"}
+}
+
+// Creates a normalizer from the names of its steps, which are applied in
+// the order of 'StepNames' whatever their order in 'names'. When 'keepParams'
+// or 'stripParams' are not empty, the query parameters are filtered first,
+// and the "strip-query" step is left out so that the filter is not undone.
+func FromNames(names []string, keepParams []string, stripParams []string) (Normalizer, error) {
+    selected := make(map [string] bool)
+    for _, name := range names {
+        if _, exists := namedSteps[name]; !exists {
+            return Normalizer{}, errors.New("unknown normalization step '" + name + "'")
+        }
+        selected[name] = true
+    }
+
+    for _, glob := range append(append([]string{}, keepParams...), stripParams...) {
+        if _, err := path.Match(glob, ""); err != nil {
+            return Normalizer{}, err
+        }
+    }
+
+    var steps []Step
+    if len(keepParams) > 0 || len(stripParams) > 0 {
+        steps = append(steps, FilterQuery(keepParams, stripParams))
+        delete(selected, "strip-query")
+    }
+
+    for _, name := range stepNames {
+        if selected[name] {
+            steps = append(steps, namedSteps[name])
+        }
+    }
+
+    return New(steps...), nil
+}
+
+// Removes the whole query string.
+var StripQuery = StepFunc(func(u *url.URL) {
+    u.RawQuery = ""
+})
+
+// Sorts the query parameters by name.
+var SortQuery = StepFunc(func(u *url.URL) {
+    if u.RawQuery != "" {
+        u.RawQuery = u.Query().Encode()
+    }
+})
+
+var LowercaseHost = StepFunc(func(u *url.URL) {
+    u.Host = strings.ToLower(u.Host)
+})
+
+// Removes the port when it is the default one of the scheme.
+var RemoveDefaultPort = StepFunc(func(u *url.URL) {
+    port := u.Port()
+    if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+        u.Host = strings.TrimSuffix(u.Host, ":" + port)
+    }
+})
+
+// Resolves the '.' and '..' segments of the path.
+var ResolveDotSegments = StepFunc(func(u *url.URL) {
+    u.Path = removeDotSegments(u.Path)
+    u.RawPath = removeDotSegments(u.RawPath)
+})
+
+// Removes the 'index.html' or 'index.htm' last segment of the path.
+var CollapseIndex = StepFunc(func(u *url.URL) {
+    if base := path.Base(u.Path); base == "index.html" || base == "index.htm" {
+        u.Path = strings.TrimSuffix(u.Path, base)
+        u.RawPath = ""
+    }
+})
+
+var TrimTrailingSlash = StepFunc(func(u *url.URL) {
+    u.Path = strings.TrimRight(u.Path, "/")
+    u.RawPath = strings.TrimRight(u.RawPath, "/")
+})
+
+// Decodes the percent-encoded unreserved characters of the path and query,
+// and uses uppercase hexadecimal digits for the rest.
+var NormalizeEncoding = StepFunc(func(u *url.URL) {
+    escapedPath := normalizeEscapes(u.EscapedPath())
+    if decodedPath, err := url.PathUnescape(escapedPath); err == nil {
+        u.Path = decodedPath
+        u.RawPath = escapedPath
+    }
+
+    u.RawQuery = normalizeEscapes(u.RawQuery)
+})
+
+// Keeps only the query parameters whose names match some of the 'keep'
+// globs, if any, and removes the ones matching some of the 'strip' globs,
+// e.g. "utm_*". The order of the remaining parameters is kept.
+func FilterQuery(keep []string, strip []string) Step {
+    return StepFunc(func(u *url.URL) {
+        if u.RawQuery == "" {
+            return
+        }
+
+        var kept []string
+        for _, param := range strings.Split(u.RawQuery, "&") {
+            name := param
+            if eq := strings.IndexByte(param, '='); eq >= 0 {
+                name = param[:eq]
+            }
+            if unescaped, err := url.QueryUnescape(name); err == nil {
+                name = unescaped
+            }
+
+            if (len(keep) > 0 && !matchesAny(keep, name)) || matchesAny(strip, name) {
+                continue
+            }
+
+            kept = append(kept, param)
+        }
+
+        u.RawQuery = strings.Join(kept, "&")
+    })
+}
+
+func matchesAny(globs []string, name string) bool {
+    for _, glob := range globs {
+        if matched, _ := path.Match(glob, name); matched {
+            return true
+        }
+    }
+
+    return false
+}
+
+// Removes the '.' and '..' segments of a path as described in RFC 3986,
+// section 5.2.4.
+func removeDotSegments(p string) string {
+    if p == "" {
+        return p
+    }
+
+    segments := strings.Split(p, "/")
+    var out []string
+
+    for i, segment := range segments {
+        switch segment {
+            case ".":
+            case "..":
+                if len(out) > 1 {
+                    out = out[:len(out)-1]
+                }
+            default:
+                out = append(out, segment)
+                continue
+        }
+
+        // A trailing dot segment leaves the path ending with '/'
+        if i == len(segments) - 1 {
+            out = append(out, "")
+        }
+    }
+
+    return strings.Join(out, "/")
+}
+
+// Decodes the percent-encoded unreserved characters and uppercases the
+// hexadecimal digits of the rest of the percent-encodings.
+func normalizeEscapes(s string) string {
+    var b strings.Builder
+
+    for i := 0; i < len(s); i++ {
+        if s[i] == '%' && i + 2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]) {
+            c := unhex(s[i+1]) << 4 | unhex(s[i+2])
+            if isUnreserved(c) {
+                b.WriteByte(c)
+            } else {
+                b.WriteString("%" + strings.ToUpper(s[i+1:i+3]))
+            }
+
+            i += 2
+            continue
+        }
+
+        b.WriteByte(s[i])
+    }
+
+    return b.String()
+}
+
+func isHex(c byte) bool {
+    return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
+func unhex(c byte) byte {
+    switch {
+        case '0' <= c && c <= '9':
+            return c - '0'
+        case 'a' <= c && c <= 'f':
+            return c - 'a' + 10
+        default:
+            return c - 'A' + 10
+    }
+}
+
+func isUnreserved(c byte) bool {
+    return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') ||
+        c == '-' || c == '.' || c == '_' || c == '~'
+}
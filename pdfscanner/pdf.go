@@ -0,0 +1,403 @@
+package pdfscanner
+
+import (
+    "bytes"
+    "compress/zlib"
+    "html"
+    "io"
+    "io/ioutil"
+    "regexp"
+    "strconv"
+    "strings"
+    "unicode/utf16"
+)
+
+// Maximum size of a decompressed stream.
+const maxStreamSize = 64 * 1024 * 1024
+
+// Object of a PDF document, without its stream.
+type pdfObject struct {
+    number int
+    text   []byte
+}
+
+// Contents of a PDF document relevant to find its title and its links.
+type pdfDocument struct {
+    // Objects in the order they were found. Objects are found again when
+    // updated.
+    objects  []pdfObject
+    byNumber map [int] []byte
+    // Decoded metadata streams, with XMP metadata
+    metadata [][]byte
+    // Whether its strings are encrypted
+    encrypted bool
+    // Number of its information dictionary object, zero when unknown
+    infoNumber int
+}
+
+var (
+    objectHeaderRegexp = regexp.MustCompile(`(\d+)\s+\d+\s+obj\b`)
+    streamOrEndRegexp = regexp.MustCompile(`\bstream(?:\r\n|\n|\r)|\bendobj\b`)
+    lengthRegexp = regexp.MustCompile(`/Length\s+(\d+)(\s+\d+\s+R)?`)
+    infoRegexp = regexp.MustCompile(`/Info\s+(\d+)\s+\d+\s+R`)
+    encryptRegexp = regexp.MustCompile(`/Encrypt\b`)
+    objectStreamRegexp = regexp.MustCompile(`/Type\s*/ObjStm\b`)
+    metadataRegexp = regexp.MustCompile(`/Type\s*/Metadata\b`)
+    filterRegexp = regexp.MustCompile(`/Filter\s*(?:\[((?:\s*/\w+)*)\s*\]|(/\w+))`)
+    firstRegexp = regexp.MustCompile(`/First\s+(\d+)`)
+    uriRegexp = regexp.MustCompile(`/URI\s*(?:(\d+)\s+\d+\s+R\b|[(<])`)
+    titleRegexp = regexp.MustCompile(`/Title\s*(?:(\d+)\s+\d+\s+R\b|[(<])`)
+    xmpTitleRegexp = regexp.MustCompile(`(?s)<dc:title>.*?<rdf:li[^>]*>(.*?)</rdf:li>`)
+)
+
+// Parses the objects of a PDF document, including the ones in compressed
+// object streams. The cross-reference tables are not needed, objects are
+// found in the order they are in the document.
+func parsePdf(contents []byte) *pdfDocument {
+    doc := &pdfDocument{byNumber: make(map [int] []byte)}
+
+    for pos := 0; pos < len(contents); {
+        header := objectHeaderRegexp.FindSubmatchIndex(contents[pos:])
+        if header == nil {
+            break
+        }
+
+        number, _ := strconv.Atoi(string(contents[pos+header[2]:pos+header[3]]))
+        bodyStart := pos + header[1]
+
+        end := streamOrEndRegexp.FindIndex(contents[bodyStart:])
+        if end == nil {
+            doc.addObject(number, contents[bodyStart:])
+            break
+        }
+
+        text := contents[bodyStart:bodyStart+end[0]]
+        doc.addObject(number, text)
+        pos = bodyStart + end[1]
+
+        if bytes.HasPrefix(contents[bodyStart+end[0]:], []byte("stream")) {
+            data, next := streamData(contents, pos, text)
+            pos = next
+
+            // Other streams, e.g. page contents or images, are not decoded
+            isObjectStream, isMetadata := objectStreamRegexp.Match(text), metadataRegexp.Match(text)
+            if !isObjectStream && !isMetadata {
+                continue
+            }
+
+            if decoded, isDecoded := decodeStream(text, data); isDecoded && isObjectStream {
+                doc.addCompressedObjects(text, decoded)
+            } else if isDecoded {
+                doc.metadata = append(doc.metadata, decoded)
+            }
+        }
+    }
+
+    doc.encrypted = encryptRegexp.Match(contents)
+
+    if infos := infoRegexp.FindAllSubmatch(contents, -1); len(infos) > 0 {
+        doc.infoNumber, _ = strconv.Atoi(string(infos[len(infos)-1][1]))
+    }
+
+    return doc
+}
+
+func (doc *pdfDocument) addObject(number int, text []byte) {
+    doc.objects = append(doc.objects, pdfObject{number, text})
+    doc.byNumber[number] = text
+}
+
+// Gets the data of a stream starting at 'start', whose object dictionary is
+// 'dict', and the position after its end.
+func streamData(contents []byte, start int, dict []byte) (data []byte, next int) {
+    if length := lengthRegexp.FindSubmatch(dict); length != nil && length[2] == nil {
+        n, err := strconv.Atoi(string(length[1]))
+        if err == nil && n >= 0 && start + n <= len(contents) &&
+            bytes.HasPrefix(bytes.TrimLeft(contents[start+n:], "\r\n \t"), []byte("endstream")) {
+
+            return contents[start:start+n], start + n
+        }
+    }
+
+    // The length is unknown or wrong, the stream ends at its end keyword
+    end := bytes.Index(contents[start:], []byte("endstream"))
+    if end < 0 {
+        return contents[start:], len(contents)
+    }
+
+    return contents[start:start+end], start + end
+}
+
+// Decodes the data of a stream, when it has no filters or is compressed
+// with the Flate algorithm only.
+func decodeStream(dict []byte, data []byte) ([]byte, bool) {
+    if !bytes.Contains(dict, []byte("/Filter")) {
+        return data, true
+    }
+
+    // Either an array of filters or a single one
+    filters := filterRegexp.FindSubmatch(dict)
+    if filters == nil {
+        return nil, false
+    }
+
+    names := filters[2]
+    if filters[1] != nil {
+        names = filters[1]
+    }
+    if !bytes.Equal(bytes.TrimSpace(names), []byte("/FlateDecode")) {
+        return nil, false
+    }
+
+    reader, err := zlib.NewReader(bytes.NewReader(data))
+    if err != nil {
+        return nil, false
+    }
+    defer reader.Close()
+
+    // Keep what could be decompressed of damaged streams
+    decoded, err := ioutil.ReadAll(io.LimitReader(reader, maxStreamSize))
+    if err != nil && len(decoded) == 0 {
+        return nil, false
+    }
+
+    return decoded, true
+}
+
+// Adds the objects of a decoded object stream, which starts with pairs of
+// object numbers and offsets from the position in its '/First' entry.
+func (doc *pdfDocument) addCompressedObjects(dict []byte, decoded []byte) {
+    firstMatch := firstRegexp.FindSubmatch(dict)
+    if firstMatch == nil {
+        return
+    }
+
+    first, err := strconv.Atoi(string(firstMatch[1]))
+    if err != nil || first < 0 || first > len(decoded) {
+        return
+    }
+
+    // Offsets of damaged or crafted streams may be out of the stream or in
+    // the wrong order, the objects are then not trusted
+    header := bytes.Fields(decoded[:first])
+    var numbers, offsets []int
+    for i := 0; i + 1 < len(header); i += 2 {
+        number, numberErr := strconv.Atoi(string(header[i]))
+        offset, offsetErr := strconv.Atoi(string(header[i+1]))
+        if numberErr != nil || offsetErr != nil || offset < 0 || offset > len(decoded) - first ||
+            (len(offsets) > 0 && first + offset <= offsets[len(offsets)-1]) {
+            return
+        }
+
+        numbers = append(numbers, number)
+        offsets = append(offsets, first + offset)
+    }
+
+    for i, number := range numbers {
+        end := len(decoded)
+        if i + 1 < len(offsets) {
+            end = offsets[i+1]
+        }
+
+        doc.addObject(number, decoded[offsets[i]:end])
+    }
+}
+
+// Gets the title of the document, from its information dictionary or else
+// from its XMP metadata.
+func (doc *pdfDocument) title() string {
+    if info, exists := doc.byNumber[doc.infoNumber]; exists && !doc.encrypted {
+        if titles := doc.stringValues(info, titleRegexp); len(titles) > 0 {
+            if title := strings.TrimSpace(textString(titles[0])); title != "" {
+                return title
+            }
+        }
+    }
+
+    for _, stream := range doc.metadata {
+        if match := xmpTitleRegexp.FindSubmatch(stream); match != nil {
+            return html.UnescapeString(string(bytes.TrimSpace(match[1])))
+        }
+    }
+
+    return ""
+}
+
+// Gets the URIs of the URI actions of the document, e.g. of its link
+// annotations, in the order they were found and without repetitions.
+func (doc *pdfDocument) uris() []string {
+    if doc.encrypted {
+        return nil
+    }
+
+    var uris []string
+    found := make(map [string] bool)
+
+    for _, object := range doc.objects {
+        for _, value := range doc.stringValues(object.text, uriRegexp) {
+            uri := string(bytes.TrimSpace(value))
+            if uri != "" && !found[uri] {
+                uris = append(uris, uri)
+                found[uri] = true
+            }
+        }
+    }
+
+    return uris
+}
+
+// Gets the string values of the entries of 'text' matched by 'keyRegexp',
+// which matches the key followed by an indirect reference, or by the start
+// of a literal or hexadecimal string.
+func (doc *pdfDocument) stringValues(text []byte, keyRegexp *regexp.Regexp) [][]byte {
+    var values [][]byte
+
+    for _, match := range keyRegexp.FindAllSubmatchIndex(text, -1) {
+        if match[2] >= 0 {
+            number, _ := strconv.Atoi(string(text[match[2]:match[3]]))
+            if referenced, exists := doc.byNumber[number]; exists {
+                referenced = bytes.TrimSpace(referenced)
+                if value, isString := parseString(referenced); isString {
+                    values = append(values, value)
+                }
+            }
+        } else if value, isString := parseString(text[match[1]-1:]); isString {
+            values = append(values, value)
+        }
+    }
+
+    return values
+}
+
+// Parses the literal, e.g. "(text)", or hexadecimal, e.g. "<74657874>",
+// string at the start of 'text'.
+func parseString(text []byte) ([]byte, bool) {
+    if len(text) == 0 {
+        return nil, false
+    }
+
+    switch text[0] {
+        case '(':
+            return parseLiteralString(text[1:])
+        case '<':
+            if len(text) > 1 && text[1] == '<' {
+                // A dictionary
+                return nil, false
+            }
+            return parseHexString(text[1:])
+    }
+
+    return nil, false
+}
+
+func parseLiteralString(text []byte) ([]byte, bool) {
+    var value []byte
+    depth := 0
+
+    for i := 0; i < len(text); i++ {
+        c := text[i]
+
+        switch {
+            case c == '\\' && i + 1 < len(text):
+                i++
+                switch e := text[i]; e {
+                    case 'n':
+                        value = append(value, '\n')
+                    case 'r':
+                        value = append(value, '\r')
+                    case 't':
+                        value = append(value, '\t')
+                    case 'b':
+                        value = append(value, '\b')
+                    case 'f':
+                        value = append(value, '\f')
+                    case '\r':
+                        // Line continuation
+                        if i + 1 < len(text) && text[i+1] == '\n' {
+                            i++
+                        }
+                    case '\n':
+                    default:
+                        if e >= '0' && e <= '7' {
+                            octal := int(e - '0')
+                            for digits := 1; digits < 3 && i + 1 < len(text) && text[i+1] >= '0' && text[i+1] <= '7'; digits++ {
+                                i++
+                                octal = octal * 8 + int(text[i] - '0')
+                            }
+                            value = append(value, byte(octal))
+                        } else {
+                            value = append(value, e)
+                        }
+                }
+
+            case c == '(':
+                depth++
+                value = append(value, c)
+
+            case c == ')':
+                if depth == 0 {
+                    return value, true
+                }
+                depth--
+                value = append(value, c)
+
+            default:
+                value = append(value, c)
+        }
+    }
+
+    return nil, false
+}
+
+func parseHexString(text []byte) ([]byte, bool) {
+    end := bytes.IndexByte(text, '>')
+    if end < 0 {
+        return nil, false
+    }
+
+    var digits []byte
+    for _, c := range text[:end] {
+        switch {
+            case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+                digits = append(digits, c)
+            case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\f':
+            default:
+                return nil, false
+        }
+    }
+
+    if len(digits) % 2 == 1 {
+        digits = append(digits, '0')
+    }
+
+    value := make([]byte, len(digits) / 2)
+    for i := range value {
+        b, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+        value[i] = byte(b)
+    }
+
+    return value, true
+}
+
+// Decodes a PDF text string, either UTF-16 with a byte order mark, UTF-8
+// with a byte order mark, or else in PDFDocEncoding, handled as Latin-1.
+func textString(value []byte) string {
+    switch {
+        case bytes.HasPrefix(value, []byte{0xfe, 0xff}):
+            var units []uint16
+            for i := 2; i + 1 < len(value); i += 2 {
+                units = append(units, uint16(value[i]) << 8 | uint16(value[i+1]))
+            }
+            return string(utf16.Decode(units))
+
+        case bytes.HasPrefix(value, []byte{0xef, 0xbb, 0xbf}):
+            return string(value[3:])
+    }
+
+    runes := make([]rune, len(value))
+    for i, b := range value {
+        runes[i] = rune(b)
+    }
+
+    return string(runes)
+}
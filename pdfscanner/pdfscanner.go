@@ -0,0 +1,102 @@
+package pdfscanner
+
+import (
+    "go.uber.org/zap"
+    "io"
+    "io/ioutil"
+    "webCrawler/crawler"
+)
+
+type Options struct {
+    // Maximum number of links sent in a single message.
+    LinksPerMsg int
+
+    // Maximum number of bytes read from a document. Only the start of
+    // larger documents is scanned.
+    MaxSize int64
+
+    Logger *zap.Logger
+}
+
+func DefaultOptions() Options {
+    logger, _ := zap.NewProduction()
+
+    return Options {
+        LinksPerMsg: 20,
+        MaxSize: 32 * 1024 * 1024,
+        Logger: logger,
+    }
+}
+
+// Scanner of PDF documents, finding their title in their metadata and the
+// links of their URI actions, e.g. of their link annotations. Encrypted
+// documents are only scanned for their XMP metadata.
+type PdfScanner struct {
+    options Options
+}
+
+func New(options Options) crawler.Scanner {
+    if options.Logger == nil {
+        options.Logger = zap.NewNop()
+    }
+
+    if options.LinksPerMsg < 1 {
+        options.LinksPerMsg = 1
+    }
+
+    if options.MaxSize <= 0 {
+        options.MaxSize = DefaultOptions().MaxSize
+    }
+
+    return &PdfScanner{options}
+}
+
+func (s *PdfScanner) Scan(r crawler.DocReader, outCh chan crawler.Message) {
+    logger := s.options.Logger.With(zap.String("DocId", string(r.DocId)))
+
+    contents, err := ioutil.ReadAll(io.LimitReader(r.Reader, s.options.MaxSize))
+    if err != nil {
+        logger.Debug("Error while reading document", zap.Error(err))
+    }
+
+    doc := parsePdf(contents)
+
+    if title := doc.title(); title != "" {
+        msg := crawler.Message{
+            Content: []string{title},
+            DocId: r.DocId,
+            Type: crawler.Title,
+        }
+
+        logger.Debug("Send title", zap.Object("Msg", msg))
+        outCh <- msg
+    }
+
+    uris := doc.uris()
+    for start := 0; start < len(uris); start += s.options.LinksPerMsg {
+        end := start + s.options.LinksPerMsg
+        if end > len(uris) {
+            end = len(uris)
+        }
+
+        msg := crawler.Message{
+            Content: uris[start:end],
+            DocId: r.DocId,
+            Type: crawler.Link,
+            Kind: crawler.Navigation,
+        }
+
+        logger.Debug("Send links", zap.Object("Msg", msg))
+        outCh <- msg
+    }
+
+    eos := crawler.EndOfStreamMsg(r.DocId)
+
+    logger.Debug("Send EoS", zap.Object("Msg", eos))
+    outCh <- eos
+
+    logger.Sync()
+
+    // The rest of the documents larger than the limit is not read
+    r.Reader.Close()
+}
@@ -0,0 +1,129 @@
+package pdfscanner
+
+import (
+    "bytes"
+    "compress/zlib"
+    "fmt"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "testing"
+    "webCrawler/crawler"
+)
+
+// Builds a PDF document with a page linking to 'uri', and an information
+// dictionary with 'title'. Its objects are in a compressed object stream
+// when 'compressed' is true.
+func testPdf(title string, uri string, compressed bool) []byte {
+    objects := []string{
+        "<< /Type /Catalog /Pages 2 0 R >>",
+        "<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+        "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [4 0 R] >>",
+        "<< /Type /Annot /Subtype /Link /Rect [0 0 100 20] /A << /S /URI /URI " + uri + " >> >>",
+        "<< /Title " + title + " /Producer (Test) >>",
+    }
+
+    var out bytes.Buffer
+    out.WriteString("%PDF-1.5\n%\xe2\xe3\xcf\xd3\n")
+
+    if !compressed {
+        for i, object := range objects {
+            fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i + 1, object)
+        }
+        out.WriteString("xref\n0 6\ntrailer\n<< /Size 6 /Root 1 0 R /Info 5 0 R >>\nstartxref\n0\n%%EOF\n")
+
+        return out.Bytes()
+    }
+
+    var header, body bytes.Buffer
+    for i, object := range objects {
+        fmt.Fprintf(&header, "%d %d ", i + 1, body.Len())
+        body.WriteString(object + "\n")
+    }
+
+    var stream bytes.Buffer
+    zw := zlib.NewWriter(&stream)
+    zw.Write(header.Bytes())
+    zw.Write(body.Bytes())
+    zw.Close()
+
+    fmt.Fprintf(&out, "6 0 obj\n<< /Type /ObjStm /N %d /First %d /Filter /FlateDecode /Length %d >>\nstream\n",
+        len(objects), header.Len(), stream.Len())
+    out.Write(stream.Bytes())
+    out.WriteString("\nendstream\nendobj\n")
+    out.WriteString("7 0 obj\n<< /Type /XRef /Size 8 /Root 1 0 R /Info 5 0 R /Length 0 >>\nstream\n\nendstream\nendobj\n")
+    out.WriteString("startxref\n0\n%%EOF\n")
+
+    return out.Bytes()
+}
+
+func scan(contents []byte) (title string, links []string) {
+    outCh := make(chan crawler.Message)
+    reader := crawler.DocReader{DocId: "DOC_ID", Reader: ioutil.NopCloser(bytes.NewReader(contents))}
+
+    go New(DefaultOptions()).Scan(reader, outCh)
+
+    for msg := <- outCh; msg.Type != crawler.EndOfStream; msg = <- outCh {
+        switch msg.Type {
+            case crawler.Title:
+                title = msg.Content[0]
+            case crawler.Link:
+                links = append(links, msg.Content...)
+        }
+    }
+
+    return title, links
+}
+
+func TestPdfScanner_Scan(t *testing.T) {
+    assert := assert.New(t)
+
+    title, links := scan(testPdf("(User \\(guide\\) caf\\351)", "(http://example.com/a\\(1\\))", false))
+    assert.Equal("User (guide) café", title)
+    assert.Equal([]string{"http://example.com/a(1)"}, links)
+
+    title, links = scan(testPdf("<FEFF00470075 0069006400650020263A>", "<687474703A2F2F6578616D706C652E636F6D2F62>", true))
+    assert.Equal("Guide ☺", title)
+    assert.Equal([]string{"http://example.com/b"}, links)
+
+    title, links = scan([]byte("not a PDF"))
+    assert.Equal("", title)
+    assert.Empty(links)
+}
+
+func TestPdfScanner_ScanXmpTitle(t *testing.T) {
+    assert := assert.New(t)
+
+    xmp := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF><rdf:Description>` +
+        `<dc:title><rdf:Alt><rdf:li xml:lang="x-default">Fish &amp; chips</rdf:li></rdf:Alt></dc:title>` +
+        `</rdf:Description></rdf:RDF></x:xmpmeta>`
+    contents := fmt.Sprintf("%%PDF-1.4\n1 0 obj\n<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream\nendobj\n",
+        len(xmp), xmp)
+
+    title, _ := scan([]byte(contents))
+    assert.Equal("Fish & chips", title)
+}
+
+func TestParsePdf_KeepsOnlyMetadataStreams(t *testing.T) {
+    assert := assert.New(t)
+
+    xmp := `<dc:title><rdf:Alt><rdf:li>Page text</rdf:li></rdf:Alt></dc:title>`
+    contents := fmt.Sprintf("%%PDF-1.4\n1 0 obj\n<< /Length %d >>\nstream\n%s\nendstream\nendobj\n",
+        len(xmp), xmp)
+
+    doc := parsePdf([]byte(contents))
+    assert.Empty(doc.metadata, "Expected page contents not to be kept")
+    assert.Equal("", doc.title())
+}
+
+func TestParsePdf_IgnoresMalformedObjectStreams(t *testing.T) {
+    assert := assert.New(t)
+
+    for _, header := range []string{"5 -9 ", "5 0 6 0 ", "5 2 6 1 ", "5 99 "} {
+        objects := header + "(x)"
+        contents := fmt.Sprintf("%%PDF-1.5\n1 0 obj\n<< /Type /ObjStm /N 1 /First %d /Length %d >>\nstream\n%s\nendstream\nendobj\n",
+            len(header), len(objects), objects)
+
+        assert.NotPanics(func() { parsePdf([]byte(contents)) }, "Header '%s'", header)
+        assert.Empty(parsePdf([]byte(contents)).byNumber[5], "Header '%s'", header)
+    }
+}
\ No newline at end of file
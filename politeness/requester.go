@@ -0,0 +1,269 @@
+package politeness
+
+import (
+    "context"
+    "fmt"
+    "go.uber.org/zap"
+    "io"
+    "sync"
+    "time"
+    "webCrawler/crawler"
+)
+
+// Error returned by a requester when the server asked to slow down, e.g.
+// with a 429 or 503 HTTP status.
+type ThrottledError struct {
+    StatusCode int
+
+    // Time the server asked to wait before retrying, zero if not given.
+    RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+    return fmt.Sprintf("server throttled the request with status %d", e.StatusCode)
+}
+
+type Options struct {
+    // Maximum number of requests started per second to a single host.
+    // Zero or a negative value means no limit.
+    RequestsPerSecond float64
+
+    // Minimum time between the start of two requests to a single host.
+    MinDelay time.Duration
+
+    // Maximum number of requests in progress to a single host, including
+    // the ones whose body was not closed yet. Zero or a negative value
+    // means no limit.
+    MaxConnsPerHost int
+
+    // Number of times a throttled request is retried before giving up.
+    MaxRetries int
+
+    // Maximum time waited before retrying a throttled request, also used
+    // to bound the server 'Retry-After' values.
+    MaxBackoff time.Duration
+
+    // Gets the delay between requests asked by the host serving a document,
+    // e.g. through the 'Crawl-delay' of its robots.txt file. May be nil.
+    CrawlDelay func(ctx context.Context, docId crawler.DocId) time.Duration
+
+    // Gets the host serving the document with the given id.
+    HostOf func(docId crawler.DocId) string
+
+    Logger *zap.Logger
+}
+
+func DefaultOptions() Options {
+    logger, _ := zap.NewProduction()
+
+    return Options {
+        RequestsPerSecond: 4,
+        MinDelay: 0,
+        MaxConnsPerHost: 2,
+        MaxRetries: 3,
+        MaxBackoff: time.Minute,
+        CrawlDelay: nil,
+        HostOf: crawler.UrlHost,
+        Logger: logger,
+    }
+}
+
+type host struct {
+    connsCh chan struct{}
+
+    mutex       sync.Mutex
+    nextRequest time.Time
+    interval    time.Duration
+    initialized bool
+}
+
+// Requester decorator limiting the rate and number of simultaneous
+// requests sent to every host.
+type Requester struct {
+    next    crawler.Requester
+    options Options
+    hosts   map [string] *host
+    mutex   sync.Mutex
+    logger  *zap.Logger
+}
+
+func NewRequester(next crawler.Requester, options Options) crawler.Requester {
+    logger := options.Logger
+    if logger == nil {
+        logger = zap.NewNop()
+    }
+
+    if options.HostOf == nil {
+        options.HostOf = crawler.UrlHost
+    }
+
+    return &Requester{
+        next: next,
+        options: options,
+        hosts: make(map [string] *host),
+        logger: logger,
+    }
+}
+
+func (r *Requester) Request(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+    h := r.host(ctx, docId)
+
+    if h.connsCh == nil {
+        return r.request(ctx, h, docId)
+    }
+
+    select {
+        case h.connsCh <- struct{}{}:
+        case <- ctx.Done():
+            return crawler.Response{}, ctx.Err()
+    }
+
+    // The connection is in use until the body is read, the slot of the
+    // host is released once it is closed
+    var once sync.Once
+    release := func() {
+        once.Do(func() { <- h.connsCh })
+    }
+
+    doc, err := r.request(ctx, h, docId)
+    if doc.Body == nil {
+        release()
+    } else {
+        doc.Body = &slotBody{doc.Body, release}
+    }
+
+    return doc, err
+}
+
+// Requests a document once a request slot of its host is reserved,
+// retrying while throttled.
+func (r *Requester) request(ctx context.Context, h *host, docId crawler.DocId) (crawler.Response, error) {
+    for attempt := 0; ; attempt++ {
+        if err := sleep(ctx, h.reserve()); err != nil {
+            return crawler.Response{}, err
+        }
+
+        doc, err := r.next.Request(ctx, docId)
+
+        throttled, isThrottled := err.(*ThrottledError)
+        if !isThrottled || attempt >= r.options.MaxRetries {
+            return doc, err
+        }
+
+        backoff := r.backoff(throttled.RetryAfter, attempt)
+        h.delay(backoff)
+
+        r.logger.Info("Request throttled, retrying",
+            zap.String("DocId", string(docId)),
+            zap.Int("Status", throttled.StatusCode),
+            zap.Duration("Backoff", backoff))
+    }
+}
+
+// Body of a response releasing the connection slot of its host once closed.
+type slotBody struct {
+    io.ReadCloser
+    release func()
+}
+
+func (b *slotBody) Close() error {
+    defer b.release()
+    return b.ReadCloser.Close()
+}
+
+// Gets the state of the host serving 'docId', creating it on first use.
+func (r *Requester) host(ctx context.Context, docId crawler.DocId) *host {
+    name := r.options.HostOf(docId)
+
+    r.mutex.Lock()
+    h, exists := r.hosts[name]
+    if !exists {
+        h = &host{}
+        if r.options.MaxConnsPerHost > 0 {
+            h.connsCh = make(chan struct{}, r.options.MaxConnsPerHost)
+        }
+        r.hosts[name] = h
+    }
+    r.mutex.Unlock()
+
+    h.mutex.Lock()
+    defer h.mutex.Unlock()
+
+    if !h.initialized {
+        h.interval = r.options.MinDelay
+
+        if r.options.RequestsPerSecond > 0 {
+            if perRequest := time.Duration(float64(time.Second) / r.options.RequestsPerSecond); perRequest > h.interval {
+                h.interval = perRequest
+            }
+        }
+
+        if r.options.CrawlDelay != nil {
+            if crawlDelay := r.options.CrawlDelay(ctx, docId); crawlDelay > h.interval {
+                h.interval = crawlDelay
+            }
+        }
+
+        h.initialized = true
+    }
+
+    return h
+}
+
+// Time to wait before retrying a throttled request, either the one asked
+// by the server or an exponential backoff, bounded by 'MaxBackoff'.
+func (r *Requester) backoff(retryAfter time.Duration, attempt int) time.Duration {
+    backoff := retryAfter
+    if backoff <= 0 {
+        backoff = time.Second << uint(attempt)
+    }
+
+    if r.options.MaxBackoff > 0 && backoff > r.options.MaxBackoff {
+        backoff = r.options.MaxBackoff
+    }
+
+    return backoff
+}
+
+// Reserves the next request slot of the host, returning the time to
+// wait until it.
+func (h *host) reserve() time.Duration {
+    h.mutex.Lock()
+    defer h.mutex.Unlock()
+
+    now := time.Now()
+    start := h.nextRequest
+    if start.Before(now) {
+        start = now
+    }
+
+    h.nextRequest = start.Add(h.interval)
+
+    return start.Sub(now)
+}
+
+// Delays every request to the host not started yet by 'd'.
+func (h *host) delay(d time.Duration) {
+    h.mutex.Lock()
+    defer h.mutex.Unlock()
+
+    if until := time.Now().Add(d); until.After(h.nextRequest) {
+        h.nextRequest = until
+    }
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+    if d <= 0 {
+        return ctx.Err()
+    }
+
+    timer := time.NewTimer(d)
+    defer timer.Stop()
+
+    select {
+        case <- timer.C:
+            return nil
+        case <- ctx.Done():
+            return ctx.Err()
+    }
+}
@@ -0,0 +1,200 @@
+package politeness
+
+import (
+    "context"
+    "github.com/stretchr/testify/assert"
+    "io"
+    "io/ioutil"
+    "strings"
+    "sync"
+    "testing"
+    "time"
+    "webCrawler/crawler"
+)
+
+func testOptions() Options {
+    options := DefaultOptions()
+    options.RequestsPerSecond = 0
+    options.MinDelay = 0
+    options.MaxConnsPerHost = 0
+    options.MaxBackoff = 10 * time.Millisecond
+    options.Logger = nil
+
+    return options
+}
+
+func emptyDoc() crawler.Response {
+    return crawler.Response{
+        FetchInfo: crawler.FetchInfo{StatusCode: 200},
+        Body: ioutil.NopCloser(strings.NewReader("")),
+    }
+}
+
+func TestShouldLimitSimultaneousRequestsPerHost(t *testing.T) {
+    assert := assert.New(t)
+
+    var mutex sync.Mutex
+    inProgress := map [string] int {}
+    maxInProgress := map [string] int {}
+
+    next := crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        host := crawler.UrlHost(docId)
+
+        mutex.Lock()
+        inProgress[host]++
+        if inProgress[host] > maxInProgress[host] {
+            maxInProgress[host] = inProgress[host]
+        }
+        mutex.Unlock()
+
+        time.Sleep(5 * time.Millisecond)
+
+        mutex.Lock()
+        inProgress[host]--
+        mutex.Unlock()
+
+        return emptyDoc(), nil
+    })
+
+    options := testOptions()
+    options.MaxConnsPerHost = 2
+    requester := NewRequester(next, options)
+
+    var wg sync.WaitGroup
+    for i := 0; i < 10; i++ {
+        for _, host := range []string{"http://a.example.com/", "http://b.example.com/"} {
+            wg.Add(1)
+            go func(docId crawler.DocId) {
+                defer wg.Done()
+                doc, err := requester.Request(context.Background(), docId)
+                assert.Nil(err, "Expected error to be nil")
+                doc.Body.Close()
+            }(crawler.DocId(host))
+        }
+    }
+    wg.Wait()
+
+    assert.Equal(2, maxInProgress["a.example.com"])
+    assert.Equal(2, maxInProgress["b.example.com"])
+}
+
+// Body counting the bodies of a host open at the same time.
+type countedBody struct {
+    io.Reader
+    close func()
+}
+
+func (b countedBody) Close() error {
+    b.close()
+    return nil
+}
+
+func TestShouldLimitOpenBodiesPerHost(t *testing.T) {
+    assert := assert.New(t)
+
+    var mutex sync.Mutex
+    open, maxOpen := 0, 0
+
+    next := crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        mutex.Lock()
+        open++
+        if open > maxOpen {
+            maxOpen = open
+        }
+        mutex.Unlock()
+
+        body := countedBody{strings.NewReader("contents"), func() {
+            mutex.Lock()
+            open--
+            mutex.Unlock()
+        }}
+
+        return crawler.Response{FetchInfo: crawler.FetchInfo{StatusCode: 200}, Body: body}, nil
+    })
+
+    options := testOptions()
+    options.MaxConnsPerHost = 2
+    requester := NewRequester(next, options)
+
+    var wg sync.WaitGroup
+    for i := 0; i < 10; i++ {
+        wg.Add(1)
+        go func() {
+            defer wg.Done()
+            doc, err := requester.Request(context.Background(), "http://example.com/")
+            assert.Nil(err, "Expected error to be nil")
+
+            // Reading the body keeps the connection in use
+            time.Sleep(5 * time.Millisecond)
+            ioutil.ReadAll(doc.Body)
+            doc.Body.Close()
+        }()
+    }
+    wg.Wait()
+
+    assert.Equal(2, maxOpen)
+    assert.Equal(0, open)
+}
+
+func TestShouldSpaceRequestsToTheSameHost(t *testing.T) {
+    assert := assert.New(t)
+
+    next := crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        return emptyDoc(), nil
+    })
+
+    options := testOptions()
+    options.MinDelay = 10 * time.Millisecond
+    options.CrawlDelay = func(ctx context.Context, docId crawler.DocId) time.Duration {
+        return 20 * time.Millisecond
+    }
+    requester := NewRequester(next, options)
+
+    start := time.Now()
+    for i := 0; i < 4; i++ {
+        _, err := requester.Request(context.Background(), "http://example.com/")
+        assert.Nil(err, "Expected error to be nil")
+    }
+
+    // The crawl delay is longer than the minimum delay, so it is the one used
+    assert.True(time.Since(start) >= 60 * time.Millisecond,
+        "Expected requests to be at least 20ms apart")
+}
+
+func TestShouldRetryThrottledRequests(t *testing.T) {
+    assert := assert.New(t)
+
+    attempts := 0
+    next := crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        attempts++
+        if attempts < 3 {
+            return crawler.Response{}, &ThrottledError{StatusCode: 429, RetryAfter: time.Millisecond}
+        }
+        return emptyDoc(), nil
+    })
+
+    requester := NewRequester(next, testOptions())
+
+    doc, err := requester.Request(context.Background(), "http://example.com/")
+    assert.Nil(err, "Expected error to be nil")
+    assert.NotNil(doc.Body, "Expected document body to not be nil")
+    assert.Equal(3, attempts)
+}
+
+func TestShouldGiveUpAfterMaxRetries(t *testing.T) {
+    assert := assert.New(t)
+
+    attempts := 0
+    next := crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        attempts++
+        return crawler.Response{}, &ThrottledError{StatusCode: 503}
+    })
+
+    options := testOptions()
+    options.MaxRetries = 2
+    requester := NewRequester(next, options)
+
+    _, err := requester.Request(context.Background(), "http://example.com/")
+    assert.NotNil(err, "Expected error to not be nil")
+    assert.Equal(3, attempts)
+}
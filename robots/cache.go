@@ -0,0 +1,119 @@
+package robots
+
+import (
+    "context"
+    "go.uber.org/zap"
+    "io"
+    "net/http"
+    "net/url"
+    "sync"
+)
+
+// Maximum size of a robots.txt file read, bigger files are truncated.
+const maxRobotsSize = 512 * 1024
+
+type hostRules struct {
+    mutex sync.Mutex
+    rules *Rules
+}
+
+// Fetches the robots.txt rules of every host once, and keeps them for
+// later checks. Fetches aborted by their context are not kept, so the
+// rules are fetched again by the next check. Safe to use from multiple
+// threads.
+type Cache struct {
+    client    *http.Client
+    userAgent string
+    hosts     map [string] *hostRules
+    mutex     sync.Mutex
+    logger    *zap.Logger
+}
+
+func NewCache(client *http.Client, userAgent string, logger *zap.Logger) *Cache {
+    if logger == nil {
+        logger = zap.NewNop()
+    }
+
+    return &Cache{
+        client: client,
+        userAgent: userAgent,
+        hosts: make(map [string] *hostRules),
+        logger: logger,
+    }
+}
+
+// Gets the rules for the host of 'u', fetching its robots.txt file if it
+// was not fetched yet.
+func (c *Cache) Rules(ctx context.Context, u *url.URL) *Rules {
+    origin := u.Scheme + "://" + u.Host
+
+    c.mutex.Lock()
+    entry, exists := c.hosts[origin]
+    if !exists {
+        entry = &hostRules{}
+        c.hosts[origin] = entry
+    }
+    c.mutex.Unlock()
+
+    entry.mutex.Lock()
+    defer entry.mutex.Unlock()
+
+    if entry.rules != nil {
+        return entry.rules
+    }
+
+    rules := c.fetch(ctx, origin)
+    if ctx.Err() == nil {
+        entry.rules = rules
+    }
+
+    return rules
+}
+
+// Checks whether the robots.txt file of the host of 'u' allows requesting it.
+func (c *Cache) Allowed(ctx context.Context, u *url.URL) bool {
+    path := u.EscapedPath()
+    if u.RawQuery != "" {
+        path += "?" + u.RawQuery
+    }
+
+    return c.Rules(ctx, u).Allowed(path)
+}
+
+func (c *Cache) fetch(ctx context.Context, origin string) *Rules {
+    robotsUrl := origin + "/robots.txt"
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsUrl, nil)
+    if err != nil {
+        c.logger.Warn("Invalid robots.txt URL", zap.String("URL", robotsUrl), zap.Error(err))
+        return AllowAll()
+    }
+    req.Header.Set("User-Agent", c.userAgent)
+
+    resp, err := c.client.Do(req)
+    if err != nil {
+        c.logger.Warn("Could not fetch robots.txt, disallowing the host",
+            zap.String("URL", robotsUrl), zap.Error(err))
+        return DisallowAll()
+    }
+    defer resp.Body.Close()
+
+    // As in RFC 9309, a missing file allows everything while an
+    // unreachable one disallows everything
+    switch {
+        case resp.StatusCode >= 200 && resp.StatusCode < 300:
+            rules := Parse(io.LimitReader(resp.Body, maxRobotsSize), c.userAgent)
+            c.logger.Debug("Fetched robots.txt", zap.String("URL", robotsUrl))
+            return rules
+
+        case resp.StatusCode >= 400 && resp.StatusCode < 500:
+            c.logger.Debug("No robots.txt found",
+                zap.String("URL", robotsUrl), zap.Int("Status", resp.StatusCode))
+            return AllowAll()
+
+        default:
+            c.logger.Warn("Could not fetch robots.txt, disallowing the host",
+                zap.String("URL", robotsUrl), zap.Int("Status", resp.StatusCode))
+            return DisallowAll()
+    }
+}
@@ -0,0 +1,34 @@
+package robots
+
+import (
+    "context"
+    "github.com/stretchr/testify/assert"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "testing"
+)
+
+func TestCache_DoesNotKeepCancelledFetches(t *testing.T) {
+    assert := assert.New(t)
+
+    fetches := 0
+    server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        fetches++
+        io.WriteString(w, "User-agent: *\nDisallow: /private")
+    }))
+    defer server.Close()
+
+    cache := NewCache(server.Client(), "webCrawler/1.0", nil)
+    page, _ := url.Parse(server.URL + "/page")
+
+    cancelledCtx, cancel := context.WithCancel(context.Background())
+    cancel()
+    assert.False(cache.Allowed(cancelledCtx, page))
+
+    assert.True(cache.Allowed(context.Background(), page))
+    private, _ := url.Parse(server.URL + "/private")
+    assert.False(cache.Allowed(context.Background(), private))
+    assert.Equal(1, fetches, "Expected the rules to be fetched once")
+}
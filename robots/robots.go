@@ -0,0 +1,200 @@
+package robots
+
+import (
+    "bufio"
+    "io"
+    "strconv"
+    "strings"
+    "time"
+)
+
+type rule struct {
+    allow   bool
+    pattern string
+}
+
+// Rules of a robots.txt file that apply to a single user agent.
+type Rules struct {
+    rules      []rule
+    crawlDelay time.Duration
+    sitemaps   []string
+}
+
+// Rules allowing every path, used when a host has no robots.txt file.
+func AllowAll() *Rules {
+    return &Rules{}
+}
+
+// Rules disallowing every path, used when the robots.txt file of a host
+// can't be reached.
+func DisallowAll() *Rules {
+    return &Rules{rules: []rule{{allow: false, pattern: "/"}}}
+}
+
+type group struct {
+    agents     []string
+    rules      []rule
+    crawlDelay time.Duration
+}
+
+// Parses a robots.txt file and keeps the rules of the group matching
+// 'userAgent'. Groups naming the user agent take precedence over the
+// '*' group. Unknown or malformed lines are ignored.
+func Parse(r io.Reader, userAgent string) *Rules {
+    var groups []*group
+    var current *group
+    var sitemaps []string
+    lastWasAgent := false
+
+    scanner := bufio.NewScanner(r)
+    for scanner.Scan() {
+        line := scanner.Text()
+        if comment := strings.IndexByte(line, '#'); comment >= 0 {
+            line = line[:comment]
+        }
+
+        sep := strings.IndexByte(line, ':')
+        if sep < 0 {
+            continue
+        }
+
+        key := strings.ToLower(strings.TrimSpace(line[:sep]))
+        value := strings.TrimSpace(line[sep+1:])
+
+        switch key {
+            case "user-agent":
+                if !lastWasAgent || current == nil {
+                    current = &group{}
+                    groups = append(groups, current)
+                }
+                current.agents = append(current.agents, strings.ToLower(value))
+                lastWasAgent = true
+                continue
+
+            case "allow", "disallow":
+                // An empty disallow allows everything, same as having no rule
+                if current != nil && value != "" {
+                    current.rules = append(current.rules, rule{key == "allow", value})
+                }
+
+            case "sitemap":
+                // Sitemap lines don't belong to any group
+                if value != "" {
+                    sitemaps = append(sitemaps, value)
+                }
+
+            case "crawl-delay":
+                if seconds, err := strconv.ParseFloat(value, 64); current != nil && err == nil && seconds > 0 {
+                    current.crawlDelay = time.Duration(seconds * float64(time.Second))
+                }
+        }
+
+        lastWasAgent = false
+    }
+
+    rules := &Rules{sitemaps: sitemaps}
+    for _, g := range selectGroups(groups, userAgent) {
+        rules.rules = append(rules.rules, g.rules...)
+        if g.crawlDelay > rules.crawlDelay {
+            rules.crawlDelay = g.crawlDelay
+        }
+    }
+
+    return rules
+}
+
+// Gets the groups naming the product token of 'userAgent', compared
+// without case, or the '*' groups when none does.
+func selectGroups(groups []*group, userAgent string) []*group {
+    token := strings.ToLower(userAgent)
+    if end := strings.IndexAny(token, "/ "); end >= 0 {
+        token = token[:end]
+    }
+
+    var matching, wildcard []*group
+
+    for _, g := range groups {
+        for _, agent := range g.agents {
+            if agent == "*" {
+                wildcard = append(wildcard, g)
+                break
+            } else if token != "" && agent == token {
+                matching = append(matching, g)
+                break
+            }
+        }
+    }
+
+    if len(matching) > 0 {
+        return matching
+    }
+
+    return wildcard
+}
+
+// Checks whether the rules allow requesting 'path', which should include
+// the query string if any. The longest matching rule wins, and allow rules
+// win over disallow rules of the same length.
+func (r *Rules) Allowed(path string) bool {
+    if path == "" {
+        path = "/"
+    }
+
+    allowed := true
+    longest := -1
+
+    for _, rl := range r.rules {
+        if !matches(rl.pattern, path) {
+            continue
+        }
+
+        if len(rl.pattern) > longest || (len(rl.pattern) == longest && rl.allow) {
+            allowed = rl.allow
+            longest = len(rl.pattern)
+        }
+    }
+
+    return allowed
+}
+
+// Minimum time between two requests to the host, zero if not set.
+func (r *Rules) CrawlDelay() time.Duration {
+    return r.crawlDelay
+}
+
+// URLs of the sitemap files listed by the robots.txt file, whatever the
+// user agent.
+func (r *Rules) Sitemaps() []string {
+    return r.sitemaps
+}
+
+// Matches a path against a rule pattern, where '*' matches any sequence
+// of characters and a trailing '$' anchors the pattern to the end of
+// the path.
+func matches(pattern string, path string) bool {
+    anchored := strings.HasSuffix(pattern, "$")
+    if anchored {
+        pattern = pattern[:len(pattern)-1]
+    }
+
+    parts := strings.Split(pattern, "*")
+
+    if !strings.HasPrefix(path, parts[0]) {
+        return false
+    }
+    pos := len(parts[0])
+
+    for i := 1; i < len(parts); i++ {
+        if i == len(parts) - 1 && anchored {
+            return strings.HasSuffix(path[pos:], parts[i])
+        }
+
+        idx := strings.Index(path[pos:], parts[i])
+        if idx < 0 {
+            return false
+        }
+        pos += idx + len(parts[i])
+    }
+
+    return !anchored || pos == len(path)
+}
@@ -0,0 +1,134 @@
+package robots
+
+import (
+    "github.com/stretchr/testify/assert"
+    "strings"
+    "testing"
+    "time"
+)
+
+type robotsTest struct {
+    desc string            // A short description of the test case.
+    robotsTxt string       // The robots.txt file contents.
+    userAgent string       // User agent selecting the rules.
+    allowed []string       // Paths expected to be allowed.
+    disallowed []string    // Paths expected to be disallowed.
+}
+
+var robotsTests = []robotsTest{
+    {
+        "empty file",
+        "",
+        "webCrawler/1.0",
+        []string{"/", "/page"},
+        nil,
+    },
+    {
+        "disallow everything",
+        "User-agent: *\nDisallow: /",
+        "webCrawler/1.0",
+        nil,
+        []string{"/", "/page"},
+    },
+    {
+        "empty disallow allows everything",
+        "User-agent: *\nDisallow:",
+        "webCrawler/1.0",
+        []string{"/", "/page"},
+        nil,
+    },
+    {
+        "longest match wins",
+        "User-agent: *\nDisallow: /private\nAllow: /private/public",
+        "webCrawler/1.0",
+        []string{"/", "/private/public/page"},
+        []string{"/private", "/private/page"},
+    },
+    {
+        "wildcards and end anchor",
+        "User-agent: *\nDisallow: /*.pdf$\nDisallow: /search?*q=",
+        "webCrawler/1.0",
+        []string{"/doc.pdf.html", "/search"},
+        []string{"/doc.pdf", "/dir/doc.pdf", "/search?lang=en&q=test"},
+    },
+    {
+        "specific group takes precedence",
+        "User-agent: *\nDisallow: /\n\nUser-agent: webcrawler\nDisallow: /private",
+        "webCrawler/1.0",
+        []string{"/", "/page"},
+        []string{"/private"},
+    },
+    {
+        "groups with several user agents",
+        "User-agent: otherbot\nUser-agent: webcrawler\nDisallow: /a\n\nUser-agent: *\nDisallow: /b",
+        "webCrawler/1.0",
+        []string{"/b"},
+        []string{"/a"},
+    },
+    {
+        "empty user agent matches no crawler",
+        "User-agent:\nDisallow: /\n\nUser-agent: *\nDisallow: /private",
+        "webCrawler/1.0",
+        []string{"/", "/page"},
+        []string{"/private"},
+    },
+    {
+        "user agents are not matched by substring",
+        "User-agent: webcrawler-news\nDisallow: /\n\nUser-agent: crawler\nDisallow: /\n\nUser-agent: *\nDisallow: /private",
+        "webCrawler/1.0",
+        []string{"/", "/page"},
+        []string{"/private"},
+    },
+    {
+        "product token of the user agent",
+        "User-agent: WebCrawler\nDisallow: /private\n\nUser-agent: *\nDisallow: /",
+        "webcrawler (+http://example.com/bot)",
+        []string{"/", "/page"},
+        []string{"/private"},
+    },
+    {
+        "comments and unknown lines",
+        "# Comment\nUser-agent: * # all\nFoo: bar\nDisallow: /a # no a",
+        "webCrawler/1.0",
+        []string{"/b"},
+        []string{"/a"},
+    },
+}
+
+func TestParse_Allowed(t *testing.T) {
+    assert := assert.New(t)
+
+    for _, test := range robotsTests {
+        rules := Parse(strings.NewReader(test.robotsTxt), test.userAgent)
+
+        for _, path := range test.allowed {
+            assert.True(rules.Allowed(path),
+                "Test '%s' failed. Expected path '%s' to be allowed", test.desc, path)
+        }
+
+        for _, path := range test.disallowed {
+            assert.False(rules.Allowed(path),
+                "Test '%s' failed. Expected path '%s' to be disallowed", test.desc, path)
+        }
+    }
+}
+
+func TestParse_CrawlDelay(t *testing.T) {
+    assert := assert.New(t)
+
+    rules := Parse(strings.NewReader("User-agent: *\nCrawl-delay: 1.5\nDisallow: /a"), "webCrawler")
+    assert.Equal(1500 * time.Millisecond, rules.CrawlDelay())
+
+    rules = Parse(strings.NewReader("User-agent: *\nDisallow: /a"), "webCrawler")
+    assert.Equal(time.Duration(0), rules.CrawlDelay())
+}
+
+func TestParse_Sitemaps(t *testing.T) {
+    assert := assert.New(t)
+
+    rules := Parse(strings.NewReader("Sitemap: http://example.com/a.xml\n" +
+        "User-agent: otherbot\nDisallow: /\nSitemap: http://example.com/b.xml.gz"), "webCrawler")
+
+    assert.Equal([]string{"http://example.com/a.xml", "http://example.com/b.xml.gz"}, rules.Sitemaps())
+    assert.True(rules.Allowed("/"))
+}
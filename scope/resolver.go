@@ -0,0 +1,261 @@
+package scope
+
+import (
+    "golang.org/x/net/publicsuffix"
+    "net"
+    "net/url"
+    "path"
+    "regexp"
+    "strings"
+    "webCrawler/crawler"
+)
+
+// Rules deciding which links are followed during a crawl. By default only
+// links to the same host and protocol as the linking page are followed.
+type Policy struct {
+    // Host name globs, e.g. "*.example.com", whose documents are also
+    // crawled when linked from a different host.
+    IncludeHosts []string
+
+    // Host name globs whose documents are never crawled, even when linked
+    // from the same host.
+    ExcludeHosts []string
+
+    // Follow links between a host and its subdomains, e.g. from
+    // "www.example.com" to "example.com" or "blog.example.com".
+    Subdomains bool
+
+    // When not empty, only follow links whose path starts with one of
+    // these prefixes.
+    PathPrefixes []string
+
+    // Regular expressions matched against the whole link URL. When 'Allow'
+    // is not empty, only the links matching some of them are followed.
+    // Links matching some of 'Deny' are never followed.
+    Allow []string
+    Deny  []string
+
+    // Follow links to the same host using a different protocol, e.g. from
+    // http to https.
+    AnyScheme bool
+}
+
+func DefaultPolicy() Policy {
+    return Policy{
+        IncludeHosts: nil,
+        ExcludeHosts: nil,
+        Subdomains: false,
+        PathPrefixes: nil,
+        Allow: nil,
+        Deny: nil,
+        AnyScheme: false,
+    }
+}
+
+// Resolver decorator dropping the links out of the scope of a policy. The
+// ids resolved by the decorated resolver should be absolute URLs.
+type Resolver struct {
+    next   crawler.Resolver
+    policy Policy
+    allow  []*regexp.Regexp
+    deny   []*regexp.Regexp
+}
+
+// Creates the decorator of 'next', failing when some regular expression
+// or host glob of 'policy' is not valid.
+func NewResolver(next crawler.Resolver, policy Policy) (*Resolver, error) {
+    for _, glob := range append(append([]string{}, policy.IncludeHosts...), policy.ExcludeHosts...) {
+        if _, err := path.Match(glob, ""); err != nil {
+            return nil, err
+        }
+    }
+
+    allow, err := compileAll(policy.Allow)
+    if err != nil {
+        return nil, err
+    }
+
+    deny, err := compileAll(policy.Deny)
+    if err != nil {
+        return nil, err
+    }
+
+    return &Resolver{next, policy, allow, deny}, nil
+}
+
+func compileAll(exprs []string) ([]*regexp.Regexp, error) {
+    var regexps []*regexp.Regexp
+    for _, expr := range exprs {
+        re, err := regexp.Compile(expr)
+        if err != nil {
+            return nil, err
+        }
+        regexps = append(regexps, re)
+    }
+
+    return regexps, nil
+}
+
+func (r *Resolver) Resolve(locator crawler.Loc, fromId crawler.DocId) (crawler.DocId, bool) {
+    id, hasId := r.next.Resolve(locator, fromId)
+    if !hasId {
+        return "", false
+    }
+
+    linked, linkedErr := url.Parse(string(id))
+    parent, parentErr := url.Parse(string(fromId))
+    if linkedErr != nil || parentErr != nil || !r.Allows(linked, parent) {
+        return "", false
+    }
+
+    return id, true
+}
+
+// Gets the id of a web link to a host out of the scope of the policy, which
+// is not followed but kept as an external link. The id is the absolute URL
+// of the link without its fragment, as the normalization of the crawled
+// URLs, e.g. removing their query, does not apply to other sites.
+func (r *Resolver) ResolveExternal(locator crawler.Loc, fromId crawler.DocId) (crawler.DocId, bool) {
+    id, hasId := r.next.Resolve(locator, fromId)
+    if !hasId {
+        return "", false
+    }
+
+    linked, linkedErr := url.Parse(string(id))
+    parent, parentErr := url.Parse(string(fromId))
+    if linkedErr != nil || parentErr != nil || !isHttp(linked.Scheme) {
+        return "", false
+    }
+
+    // Links to the same host with a different scheme are not to other sites
+    if strings.EqualFold(linked.Hostname(), parent.Hostname()) || r.inSite(linked, parent) {
+        return "", false
+    }
+
+    absolute, err := parent.Parse(string(locator))
+    if err != nil {
+        return "", false
+    }
+    absolute.Fragment = ""
+    absolute.RawFragment = ""
+
+    return crawler.DocId(absolute.String()), true
+}
+
+// Applies the base location of a document with the decorated resolver,
+// when it supports base locations.
+func (r *Resolver) ResolveBase(locator crawler.Loc, base crawler.Loc, fromId crawler.DocId) (crawler.Loc, bool) {
+    baseResolver, canResolve := r.next.(crawler.BaseResolver)
+    if !canResolve {
+        return "", false
+    }
+
+    return baseResolver.ResolveBase(locator, base, fromId)
+}
+
+// Checks whether the policy allows following a link to 'linked' from
+// the document at 'parent'.
+func (r *Resolver) Allows(linked *url.URL, parent *url.URL) bool {
+    if !r.inSite(linked, parent) {
+        return false
+    }
+
+    if len(r.policy.PathPrefixes) > 0 && !hasAnyPrefix(linked.Path, r.policy.PathPrefixes) {
+        return false
+    }
+
+    for _, re := range r.deny {
+        if re.MatchString(linked.String()) {
+            return false
+        }
+    }
+
+    if len(r.allow) == 0 {
+        return true
+    }
+
+    for _, re := range r.allow {
+        if re.MatchString(linked.String()) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// Whether the scheme and host of 'linked' are in the scope of the policy,
+// regardless of its path.
+func (r *Resolver) inSite(linked *url.URL, parent *url.URL) bool {
+    if linked.Scheme != parent.Scheme &&
+        (!r.policy.AnyScheme || !isHttp(linked.Scheme) || !isHttp(parent.Scheme)) {
+        return false
+    }
+
+    host := strings.ToLower(linked.Hostname())
+
+    if matchesAny(r.policy.ExcludeHosts, host) {
+        return false
+    }
+
+    return r.sameSite(host, strings.ToLower(parent.Hostname())) ||
+        matchesAny(r.policy.IncludeHosts, host)
+}
+
+// Whether both hosts are the same, or one is a subdomain of the other when
+// the policy includes subdomains. A leading "www." is not taken into account.
+// Public suffixes, e.g. "com" or "co.uk", are not sites, so their subdomains
+// are never in the same site.
+func (r *Resolver) sameSite(host string, parentHost string) bool {
+    if host == parentHost {
+        return true
+    }
+
+    if !r.policy.Subdomains || net.ParseIP(host) != nil || net.ParseIP(parentHost) != nil {
+        return false
+    }
+
+    host = strings.TrimPrefix(host, "www.")
+    parentHost = strings.TrimPrefix(parentHost, "www.")
+
+    switch {
+        case host == parentHost:
+            return isRegistrable(host)
+        case strings.HasSuffix(host, "." + parentHost):
+            return isRegistrable(parentHost)
+        case strings.HasSuffix(parentHost, "." + host):
+            return isRegistrable(host)
+        default:
+            return false
+    }
+}
+
+// Whether a host name is a registrable domain or one of its subdomains, so
+// not a public suffix.
+func isRegistrable(host string) bool {
+    _, err := publicsuffix.EffectiveTLDPlusOne(host)
+    return err == nil
+}
+
+func isHttp(scheme string) bool {
+    return scheme == "http" || scheme == "https"
+}
+
+func matchesAny(globs []string, host string) bool {
+    for _, glob := range globs {
+        if matched, _ := path.Match(strings.ToLower(glob), host); matched {
+            return true
+        }
+    }
+
+    return false
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+    for _, prefix := range prefixes {
+        if strings.HasPrefix(s, prefix) {
+            return true
+        }
+    }
+
+    return false
+}
@@ -0,0 +1,153 @@
+package scope
+
+import (
+    "github.com/stretchr/testify/assert"
+    "strings"
+    "testing"
+    "webCrawler/crawler"
+)
+
+type scopeTest struct {
+    desc string              // A short description of the test case.
+    policy func(p *Policy)   // Changes to the default policy.
+    from string              // Document containing the links.
+    followed []string        // Links expected to be followed.
+    ignored []string         // Links expected to be ignored.
+}
+
+var scopeTests = []scopeTest{
+    {
+        "same host and scheme by default",
+        func(p *Policy) {},
+        "http://example.com/page",
+        []string{"http://example.com/other"},
+        []string{"https://example.com/other", "http://www.example.com", "mailto:me@example.com"},
+    },
+    {
+        "http and https as the same",
+        func(p *Policy) { p.AnyScheme = true },
+        "http://example.com/page",
+        []string{"https://example.com/other"},
+        []string{"ftp://example.com/file"},
+    },
+    {
+        "subdomains and www",
+        func(p *Policy) { p.Subdomains = true },
+        "http://www.example.com",
+        []string{"http://example.com/a", "http://blog.example.com/b"},
+        []string{"http://example.org", "http://badexample.com"},
+    },
+    {
+        "public suffixes are not sites",
+        func(p *Policy) { p.Subdomains = true },
+        "http://example.co.uk",
+        []string{"http://www.example.co.uk", "http://shop.example.co.uk"},
+        []string{"http://co.uk", "http://other.co.uk", "http://uk"},
+    },
+    {
+        "subdomains of a public suffix",
+        func(p *Policy) { p.Subdomains = true },
+        "http://com",
+        nil,
+        []string{"http://example.com", "http://www.com"},
+    },
+    {
+        "subdomains of a private public suffix",
+        func(p *Policy) { p.Subdomains = true },
+        "http://alice.github.io",
+        []string{"http://www.alice.github.io"},
+        []string{"http://github.io", "http://bob.github.io"},
+    },
+    {
+        "included and excluded host globs",
+        func(p *Policy) {
+            p.IncludeHosts = []string{"*.example.org"}
+            p.ExcludeHosts = []string{"private.*"}
+        },
+        "http://example.com",
+        []string{"http://cdn.example.org/a"},
+        []string{"http://example.org/a", "http://private.example.org"},
+    },
+    {
+        "path prefixes",
+        func(p *Policy) { p.PathPrefixes = []string{"/docs", "/blog"} },
+        "http://example.com/docs",
+        []string{"http://example.com/docs/a", "http://example.com/blog"},
+        []string{"http://example.com/about"},
+    },
+    {
+        "allow and deny regular expressions",
+        func(p *Policy) {
+            p.Allow = []string{`/(en|es)/`}
+            p.Deny = []string{`\.pdf$`}
+        },
+        "http://example.com",
+        []string{"http://example.com/en/page"},
+        []string{"http://example.com/fr/page", "http://example.com/en/doc.pdf"},
+    },
+}
+
+func TestResolver_Resolve(t *testing.T) {
+    assert := assert.New(t)
+
+    next := crawler.ResolverFunc(func(locator crawler.Loc, fromId crawler.DocId) (crawler.DocId, bool) {
+        return crawler.DocId(locator), true
+    })
+
+    for _, test := range scopeTests {
+        policy := DefaultPolicy()
+        test.policy(&policy)
+
+        resolver, err := NewResolver(next, policy)
+        assert.Nil(err)
+
+        for _, link := range test.followed {
+            _, hasId := resolver.Resolve(crawler.Loc(link), crawler.DocId(test.from))
+            assert.True(hasId, "Test '%s' failed. Expected link '%s' to be followed", test.desc, link)
+        }
+
+        for _, link := range test.ignored {
+            _, hasId := resolver.Resolve(crawler.Loc(link), crawler.DocId(test.from))
+            assert.False(hasId, "Test '%s' failed. Expected link '%s' to be ignored", test.desc, link)
+        }
+    }
+}
+
+func TestNewResolver_InvalidPolicy(t *testing.T) {
+    policy := DefaultPolicy()
+    policy.Deny = []string{"("}
+
+    _, err := NewResolver(crawler.ResolverFunc(nil), policy)
+    assert.NotNil(t, err)
+}
+
+func TestResolver_ResolveExternal(t *testing.T) {
+    assert := assert.New(t)
+
+    next := crawler.ResolverFunc(func(locator crawler.Loc, fromId crawler.DocId) (crawler.DocId, bool) {
+        return crawler.DocId(locator), true
+    })
+
+    policy := DefaultPolicy()
+    policy.PathPrefixes = []string{"/docs"}
+    resolver, _ := NewResolver(next, policy)
+
+    id, isExternal := resolver.ResolveExternal("http://other.com/page", "http://example.com/docs")
+    assert.True(isExternal)
+    assert.Equal(crawler.DocId("http://other.com/page"), id)
+
+    // The query of external links is kept even when the crawled URLs drop it
+    stripQuery := crawler.ResolverFunc(func(locator crawler.Loc, fromId crawler.DocId) (crawler.DocId, bool) {
+        return crawler.DocId(strings.SplitN(string(locator), "?", 2)[0]), true
+    })
+    resolver, _ = NewResolver(stripQuery, DefaultPolicy())
+
+    id, isExternal = resolver.ResolveExternal("https://www.youtube.com/watch?v=abc#t=10", "http://example.com/docs")
+    assert.True(isExternal)
+    assert.Equal(crawler.DocId("https://www.youtube.com/watch?v=abc"), id)
+
+    for _, link := range []crawler.Loc{"http://example.com/about", "https://example.com/docs", "mailto:me@other.com"} {
+        _, isExternal = resolver.ResolveExternal(link, "http://example.com/docs")
+        assert.False(isExternal, "Expected link '%s' not to be external", link)
+    }
+}
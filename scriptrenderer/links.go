@@ -0,0 +1,115 @@
+package scriptrenderer
+
+import (
+    "html"
+    "net/url"
+    "path"
+    "regexp"
+    "strings"
+)
+
+// Matches the string literals holding an absolute URL or a path, e.g.
+// "https://example.com/about" or '/products?page=2'.
+var urlLiteralRegexp = regexp.MustCompile(
+    "[\"'`]((?:https?:)?//[\\w.-]+(?:[/?#][^\"'`\\s<>\\\\{}()|^$]*)?|/[\\w~.-][^\"'`\\s<>\\\\{}()|^$]*)[\"'`]")
+
+// Matches the routes of client side routers, e.g. { path: 'about' } or
+// history.pushState(null, '', '/about'), with the name of their property
+// or function.
+var routeRegexps = []*regexp.Regexp{
+    regexp.MustCompile("\\b(path|route|to)\\s*:\\s*[\"'`]([^\"'`\\s<>\\\\{}()|^$]+)[\"'`]"),
+    regexp.MustCompile("\\b(pushState|replaceState)\\([^;]*?,\\s*[\"'`]([^\"'`\\s<>\\\\{}()|^$]+)[\"'`]\\s*\\)"),
+}
+
+// Extensions of the URLs of resources, e.g. script chunks or images,
+// added as resources instead of as pages to navigate to.
+var resourceExtensions = map [string] bool{
+    ".js": true, ".mjs": true, ".map": true, ".css": true, ".json": true,
+    ".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true,
+    ".webp": true, ".avif": true, ".ico": true, ".woff": true, ".woff2": true,
+    ".ttf": true, ".otf": true, ".mp3": true, ".mp4": true, ".webm": true,
+}
+
+// Links found in the scripts of a page, in order and without repetitions.
+type scriptLinks struct {
+    urls  []string
+    found map [string] bool
+}
+
+func newScriptLinks() *scriptLinks {
+    return &scriptLinks{found: make(map [string] bool)}
+}
+
+// Adds the URLs and routes found in a script.
+func (sl *scriptLinks) addFrom(script string) {
+    // Slashes are escaped in JSON data blocks
+    script = strings.ReplaceAll(script, `\/`, "/")
+
+    for _, match := range urlLiteralRegexp.FindAllStringSubmatch(script, -1) {
+        sl.add(match[1])
+    }
+
+    for _, routeRegexp := range routeRegexps {
+        for _, match := range routeRegexp.FindAllStringSubmatch(script, -1) {
+            name, route := match[1], match[2]
+
+            // Top-level routes are often declared without their leading slash
+            if name == "path" && !strings.HasPrefix(route, "/") && !strings.HasPrefix(route, ".") &&
+                !strings.Contains(route, "//") {
+                route = "/" + route
+            }
+
+            sl.add(route)
+        }
+    }
+}
+
+func (sl *scriptLinks) add(link string) {
+    if !isLink(link) || sl.found[link] {
+        return
+    }
+
+    sl.urls = append(sl.urls, link)
+    sl.found[link] = true
+}
+
+// Whether a string found in a script is a link, leaving out the routes
+// with parameters or wildcards, e.g. /users/:id or /**, and template
+// strings.
+func isLink(link string) bool {
+    if link == "" || strings.ContainsAny(link, "*$") || strings.Contains(link, "/:") {
+        return false
+    }
+
+    if strings.HasPrefix(link, "http:") || strings.HasPrefix(link, "https:") || strings.HasPrefix(link, "//") {
+        _, err := url.Parse(link)
+        return err == nil
+    }
+
+    return strings.HasPrefix(link, "/") || strings.HasPrefix(link, ".")
+}
+
+// Whether a link found in a script is a resource, by its extension.
+func isResource(link string) bool {
+    if linkUrl, err := url.Parse(link); err == nil {
+        return resourceExtensions[strings.ToLower(path.Ext(linkUrl.Path))]
+    }
+
+    return false
+}
+
+// Gets the links as HTML tags, to be appended to the page: anchors for the
+// pages to navigate to and preload links for the resources.
+func (sl *scriptLinks) html() []byte {
+    var out strings.Builder
+
+    for _, link := range sl.urls {
+        if isResource(link) {
+            out.WriteString("\n<link rel=\"preload\" href=\"" + html.EscapeString(link) + "\">")
+        } else {
+            out.WriteString("\n<a href=\"" + html.EscapeString(link) + "\"></a>")
+        }
+    }
+
+    return []byte(out.String())
+}
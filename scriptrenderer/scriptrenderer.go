@@ -0,0 +1,244 @@
+package scriptrenderer
+
+import (
+    "bytes"
+    "context"
+    "go.uber.org/zap"
+    "golang.org/x/net/html"
+    "io"
+    "io/ioutil"
+    "mime"
+    "net/url"
+    "strings"
+    "webCrawler/crawler"
+)
+
+type Options struct {
+    // Maximum number of external scripts requested for a single document.
+    // Zero means that only the inline scripts are read.
+    MaxScripts int
+
+    // Maximum number of bytes read from an external script. Only the start
+    // of larger scripts is read.
+    MaxScriptSize int64
+
+    // Maximum number of bytes read from a page. Only the start of larger
+    // pages is rendered and scanned.
+    MaxPageSize int64
+
+    // Also request the external scripts served by other hosts than the
+    // document, e.g. by a CDN.
+    OtherHostScripts bool
+
+    // Decides which external scripts are requested. When nil, every script
+    // is requested.
+    Filter crawler.Filter
+
+    Logger *zap.Logger
+}
+
+func DefaultOptions() Options {
+    logger, _ := zap.NewProduction()
+
+    return Options {
+        MaxScripts: 10,
+        MaxScriptSize: 4 * 1024 * 1024,
+        MaxPageSize: 16 * 1024 * 1024,
+        OtherHostScripts: false,
+        Filter: nil,
+        Logger: logger,
+    }
+}
+
+// Lightweight renderer of HTML pages built by their scripts, e.g. single
+// page apps. Instead of running the scripts, it looks in them for strings
+// holding URLs and for the routes of the client side router, and adds them
+// as links at the end of the page so that they are scanned with the rest
+// of its links. Other documents are rendered as they are.
+type ScriptRenderer struct {
+    requester crawler.Requester
+    options   Options
+}
+
+// Creates a renderer requesting the external scripts with 'requester'.
+func New(requester crawler.Requester, options Options) crawler.Renderer {
+    if options.Logger == nil {
+        options.Logger = zap.NewNop()
+    }
+
+    if options.MaxScriptSize <= 0 {
+        options.MaxScriptSize = DefaultOptions().MaxScriptSize
+    }
+
+    if options.MaxPageSize <= 0 {
+        options.MaxPageSize = DefaultOptions().MaxPageSize
+    }
+
+    return &ScriptRenderer{requester, options}
+}
+
+func (r *ScriptRenderer) Render(ctx context.Context, docReader crawler.DocReader) (crawler.DocReader, error) {
+    if !isHtml(docReader) {
+        return docReader, nil
+    }
+
+    logger := r.options.Logger.With(zap.String("DocId", string(docReader.DocId)))
+
+    contents, err := ioutil.ReadAll(io.LimitReader(docReader.Reader, r.options.MaxPageSize))
+    docReader.Reader.Close()
+    if err != nil {
+        return docReader, err
+    }
+
+    inline, sources := pageScripts(contents)
+
+    links := newScriptLinks()
+    for _, script := range inline {
+        links.addFrom(script)
+    }
+
+    requested := 0
+    for _, source := range sources {
+        if requested >= r.options.MaxScripts || ctx.Err() != nil {
+            break
+        }
+
+        scriptId, isRequested := r.scriptId(ctx, docReader.DocId, source)
+        if !isRequested {
+            continue
+        }
+        requested++
+
+        script, err := r.requestScript(ctx, scriptId)
+        if err != nil {
+            logger.Debug("Error while requesting script",
+                zap.String("Script", string(scriptId)),
+                zap.Error(err))
+            continue
+        }
+
+        links.addFrom(script)
+    }
+
+    logger.Debug("Found script links",
+        zap.Int("Inline scripts", len(inline)),
+        zap.Int("External scripts", requested),
+        zap.Int("Links", len(links.urls)))
+
+    docReader.Reader = ioutil.NopCloser(bytes.NewReader(append(contents, links.html()...)))
+
+    return docReader, nil
+}
+
+// Gets the id of the external script at 'source', a location relative to
+// the document with 'docId', when it should be requested.
+func (r *ScriptRenderer) scriptId(ctx context.Context, docId crawler.DocId, source string) (crawler.DocId, bool) {
+    docUrl, err := url.Parse(string(docId))
+    if err != nil {
+        return "", false
+    }
+
+    scriptUrl, err := docUrl.Parse(source)
+    if err != nil || (scriptUrl.Scheme != "http" && scriptUrl.Scheme != "https") {
+        return "", false
+    }
+
+    if !r.options.OtherHostScripts && !strings.EqualFold(scriptUrl.Hostname(), docUrl.Hostname()) {
+        return "", false
+    }
+
+    scriptUrl.Fragment = ""
+    scriptId := crawler.DocId(scriptUrl.String())
+
+    if r.options.Filter != nil {
+        if admitted, _ := r.options.Filter.Admit(ctx, scriptId); !admitted {
+            return "", false
+        }
+    }
+
+    return scriptId, true
+}
+
+// Gets the start of the script with 'scriptId', up to the maximum size.
+func (r *ScriptRenderer) requestScript(ctx context.Context, scriptId crawler.DocId) (string, error) {
+    resp, err := r.requester.Request(ctx, scriptId)
+    if resp.Body == nil {
+        return "", err
+    }
+    defer resp.Body.Close()
+
+    if err != nil {
+        return "", err
+    }
+
+    script, err := ioutil.ReadAll(io.LimitReader(resp.Body, r.options.MaxScriptSize))
+
+    return string(script), err
+}
+
+// Whether a document is an HTML page, by its media type or else by the
+// extension of its id. Documents of unknown type are taken as pages.
+func isHtml(docReader crawler.DocReader) bool {
+    if mediaType, _, err := mime.ParseMediaType(docReader.ContentType); err == nil {
+        return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+    }
+
+    switch crawler.UrlExtension(docReader.DocId) {
+        case "", ".html", ".htm":
+            return true
+        default:
+            return false
+    }
+}
+
+// Gets the contents of the inline scripts of a page, including its JSON
+// data blocks, and the locations of its external scripts.
+func pageScripts(contents []byte) (inline []string, sources []string) {
+    token := html.NewTokenizer(bytes.NewReader(contents))
+
+loopOverTokens:
+    for {
+        switch token.Next() {
+            case html.StartTagToken:
+                tagName, hasAttributes := token.TagName()
+                if string(tagName) != "script" {
+                    continue loopOverTokens
+                }
+
+                var src, scriptType string
+                for hasAttributes {
+                    var name, value []byte
+                    name, value, hasAttributes = token.TagAttr()
+
+                    switch string(name) {
+                        case "src":
+                            src = strings.TrimSpace(string(value))
+                        case "type":
+                            scriptType = strings.ToLower(strings.TrimSpace(string(value)))
+                    }
+                }
+
+                if src != "" {
+                    if isJavaScript(scriptType) {
+                        sources = append(sources, src)
+                    }
+                } else if token.Next() == html.TextToken {
+                    inline = append(inline, string(token.Text()))
+                }
+
+            case html.ErrorToken:
+                break loopOverTokens
+        }
+    }
+
+    return inline, sources
+}
+
+func isJavaScript(scriptType string) bool {
+    switch scriptType {
+        case "", "module", "text/javascript", "application/javascript", "application/ecmascript":
+            return true
+        default:
+            return false
+    }
+}
@@ -0,0 +1,115 @@
+package scriptrenderer
+
+import (
+    "context"
+    "errors"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "strings"
+    "testing"
+    "webCrawler/crawler"
+)
+
+func TestScriptLinks(t *testing.T) {
+    assert := assert.New(t)
+
+    links := newScriptLinks()
+    links.addFrom(`
+        const routes = [
+            { path: '', component: Home },
+            { path: 'about', component: About },
+            { path: '/users/:id', component: User },
+            { path: '**', component: NotFound },
+        ];
+        fetch("/api/items?page=2");
+        history.pushState({}, "", "/checkout");
+        const logo = '/static/logo.png', api = "https://api.example.com/v1";
+        const url = ` + "`/items/${id}`" + `;
+        const re = "/\\d+/", half = 1 / 2, path = "a/b";
+    `)
+    links.addFrom(`{"props":{"next":"\/products\/shoes","about":"/about"}}`)
+
+    assert.Equal([]string{
+        "/api/items?page=2", "/checkout", "/static/logo.png", "https://api.example.com/v1",
+        "/about", "/products/shoes",
+    }, links.urls)
+
+    assert.Equal("\n<a href=\"/checkout\"></a>\n<link rel=\"preload\" href=\"/static/logo.png\">",
+        string((&scriptLinks{urls: []string{"/checkout", "/static/logo.png"}}).html()))
+}
+
+func TestScriptRenderer_Render(t *testing.T) {
+    assert := assert.New(t)
+
+    var requested []crawler.DocId
+    requester := crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        requested = append(requested, docId)
+
+        if docId == "http://example.com/missing.js" {
+            return crawler.Response{FetchInfo: crawler.FetchInfo{StatusCode: 404}}, errors.New("not found")
+        }
+
+        return crawler.Response{
+            FetchInfo: crawler.FetchInfo{StatusCode: 200},
+            Body: ioutil.NopCloser(strings.NewReader(`router.push("/from-script")`)),
+        }, nil
+    })
+
+    options := DefaultOptions()
+    options.Logger = nil
+    renderer := New(requester, options)
+
+    page := `<html><head>
+        <script src="/app.js"></script>
+        <script src="missing.js"></script>
+        <script src="https://cdn.example.net/lib.js"></script>
+        <script type="text/template" src="/template.html"></script>
+        <script>window.start = "/inline";</script>
+        </head><body><div id="app"></div></body></html>`
+
+    rendered, err := renderer.Render(context.Background(), crawler.DocReader{
+        DocId: "http://example.com/page",
+        Reader: ioutil.NopCloser(strings.NewReader(page)),
+        ContentType: "text/html; charset=utf-8",
+    })
+    assert.NoError(err)
+
+    contents, _ := ioutil.ReadAll(rendered.Reader)
+    assert.Equal(page + "\n<a href=\"/inline\"></a>\n<a href=\"/from-script\"></a>", string(contents))
+    assert.Equal([]crawler.DocId{"http://example.com/app.js", "http://example.com/missing.js"}, requested)
+
+    // Other documents are rendered as they are
+    feed := crawler.DocReader{
+        DocId: "http://example.com/feed",
+        Reader: ioutil.NopCloser(strings.NewReader(`<rss><script>"/x"</script></rss>`)),
+        ContentType: "application/rss+xml",
+    }
+    rendered, err = renderer.Render(context.Background(), feed)
+    assert.NoError(err)
+    assert.Equal(feed, rendered)
+}
+
+func TestScriptRenderer_RenderLargePage(t *testing.T) {
+    assert := assert.New(t)
+
+    requester := crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        return crawler.Response{}, errors.New("not expected")
+    })
+
+    options := DefaultOptions()
+    options.Logger = nil
+    options.MaxPageSize = 32
+    renderer := New(requester, options)
+
+    page := `<html><script>var a = "/kept";</script><script>var b = "/cut";</script></html>`
+
+    rendered, err := renderer.Render(context.Background(), crawler.DocReader{
+        DocId: "http://example.com/page",
+        Reader: ioutil.NopCloser(strings.NewReader(page)),
+        ContentType: "text/html",
+    })
+    assert.NoError(err)
+
+    contents, _ := ioutil.ReadAll(rendered.Reader)
+    assert.Equal(page[:32] + "\n<a href=\"/kept\"></a>", string(contents))
+}
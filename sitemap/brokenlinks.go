@@ -0,0 +1,112 @@
+package sitemap
+
+import (
+    "io"
+    "net/http"
+    "os"
+    "sort"
+    "webCrawler/crawler"
+)
+
+// Link target that could not be fetched, along with the pages linking to it.
+type BrokenLink struct {
+    Target     crawler.DocId
+    StatusCode int
+    Error      string
+    LinkedFrom []crawler.DocId
+    // Whether the target is out of the crawl scope.
+    External   bool
+}
+
+func isBroken(doc *crawler.DocInfo) bool {
+    return doc.SkipReason == "" && (doc.Error != "" || doc.StatusCode >= 400)
+}
+
+// Gets every crawled document that failed to be fetched, and every checked
+// external link that failed, sorted by id.
+func (sm *SiteMap) BrokenLinks() []BrokenLink {
+    linkedFrom := make(map [crawler.DocId] []crawler.DocId)
+
+    for _, doc := range sm.docs {
+        seen := make(map [crawler.DocId] bool)
+
+        for _, link := range doc.Links {
+            if target, exists := sm.docs[link]; exists && isBroken(target) && !seen[link] {
+                linkedFrom[link] = append(linkedFrom[link], doc.DocId)
+                seen[link] = true
+            }
+        }
+    }
+
+    var broken []BrokenLink
+    for _, doc := range sm.docs {
+        if !isBroken(doc) {
+            continue
+        }
+
+        sources := linkedFrom[doc.DocId]
+        sort.Slice(sources, func(i, j int) bool { return sources[i] < sources[j] })
+
+        broken = append(broken, BrokenLink{
+            Target: doc.DocId,
+            StatusCode: doc.StatusCode,
+            Error: doc.Error,
+            LinkedFrom: sources,
+        })
+    }
+
+    for _, link := range sm.ExternalLinks() {
+        if link.isBroken() {
+            broken = append(broken, BrokenLink{
+                Target: link.Target,
+                StatusCode: link.StatusCode,
+                Error: link.Error,
+                LinkedFrom: link.LinkedFrom,
+                External: true,
+            })
+        }
+    }
+
+    sort.Slice(broken, func(i, j int) bool { return broken[i].Target < broken[j].Target })
+
+    return broken
+}
+
+// Writes a text report of the broken links to 'w'.
+func (sm *SiteMap) WriteBrokenLinks(w io.Writer) error {
+    ew := &errWriter{w: w}
+    broken := sm.BrokenLinks()
+
+    ew.printf("BROKEN LINKS\n" +
+        " Every link target that could not be fetched, followed by the pages linking to it.\n\n\n")
+
+    for _, link := range broken {
+        external := ""
+        if link.External {
+            external = ", external"
+        }
+
+        if link.Error != "" {
+            ew.printf(" - %s (error: %s%s)\n", link.Target, link.Error, external)
+        } else {
+            ew.printf(" - %s (%d %s%s)\n", link.Target, link.StatusCode, http.StatusText(link.StatusCode), external)
+        }
+
+        if sm.isRoot(link.Target) {
+            ew.printf("   * Starting point\n")
+        }
+
+        for _, source := range link.LinkedFrom {
+            ew.printf("   * %s\n", source)
+        }
+    }
+
+    ew.printf("\n\n%d broken links found. %s\n", len(broken), sm.stopReasonDescription())
+
+    return ew.err
+}
+
+// Prints the report of the broken links to the standard output.
+func (sm *SiteMap) PrintBrokenLinks() {
+    _ = sm.WriteBrokenLinks(os.Stdout)
+}
@@ -0,0 +1,37 @@
+package sitemap
+
+import (
+    "bytes"
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "webCrawler/crawler"
+)
+
+func TestShouldReportBrokenLinks(t *testing.T) {
+    assert := assert.New(t)
+
+    sm := testSiteMap()
+    sm.docs["http://example.com/a"].Links = append(sm.docs["http://example.com/a"].Links,
+        "http://example.com/missing", "http://example.com/missing", "http://example.com/private")
+
+    private := crawler.DefaultDocInfo("http://example.com/private")
+    private.SkipReason = "Disallowed by robots.txt"
+    sm.docs[private.DocId] = private
+
+    sm.docs["http://example.com"].StatusCode = 500
+
+    broken := sm.BrokenLinks()
+    assert.Equal([]BrokenLink{
+        {Target: "http://example.com", StatusCode: 500, LinkedFrom: []crawler.DocId{"http://example.com/a"}},
+        {Target: "http://example.com/missing", StatusCode: 404,
+            LinkedFrom: []crawler.DocId{"http://example.com", "http://example.com/a"}},
+    }, broken, "Expected skipped documents not to be broken, and every source once")
+
+    var out bytes.Buffer
+    assert.Nil(sm.WriteBrokenLinks(&out))
+    assert.Contains(out.String(),
+        " - http://example.com (500 Internal Server Error)\n   * Starting point\n   * http://example.com/a\n")
+    assert.Contains(out.String(),
+        " - http://example.com/missing (404 Not Found)\n   * http://example.com\n   * http://example.com/a\n")
+    assert.Contains(out.String(), "2 broken links found.")
+}
@@ -0,0 +1,53 @@
+package sitemap
+
+import (
+    "encoding/csv"
+    "encoding/json"
+    "io"
+    "strconv"
+    "strings"
+)
+
+// Writes the site map as a CSV edge list, with a row for every link
+// between two documents, grouped by the starting point the source was
+// reached from. The metadata of the target is written as a JSON object,
+// followed by the text, title, rel values and position of the link.
+func writeCsv(sm *SiteMap, w io.Writer) error {
+    out := csv.NewWriter(w)
+
+    _ = out.Write([]string{"seed", "source", "target", "target_status", "target_metadata",
+        "anchor_text", "link_title", "link_rel", "link_position"})
+
+    for i, ids := range sm.DocIdsBySeed() {
+        for _, id := range ids {
+            for linkIndex, link := range sm.docs[id].Links {
+                status := ""
+                metadata := ""
+                if target, wasCrawled := sm.docs[link]; wasCrawled {
+                    if target.StatusCode != 0 {
+                        status = strconv.Itoa(target.StatusCode)
+                    }
+                    if len(target.Metadata) > 0 {
+                        encoded, _ := json.Marshal(target.Metadata)
+                        metadata = string(encoded)
+                    }
+                }
+
+                details := linkDetail(sm.docs[id], linkIndex)
+                position := ""
+                if details.Position > 0 {
+                    position = strconv.Itoa(details.Position)
+                }
+
+                _ = out.Write([]string{
+                    string(sm.roots[i]), string(id), string(link), status, metadata,
+                    details.Text, details.Title, strings.Join(details.Rel, " "), position,
+                })
+            }
+        }
+    }
+
+    out.Flush()
+
+    return out.Error()
+}
@@ -0,0 +1,149 @@
+package sitemap
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "sort"
+    "sync"
+    "webCrawler/crawler"
+    "webCrawler/threadpool"
+)
+
+// Link from a crawled page to a document out of the crawl scope, e.g. on
+// another site.
+type ExternalLink struct {
+    Target     crawler.DocId
+    LinkedFrom []crawler.DocId
+
+    // Whether the target was requested to check it is still alive. The
+    // result of the request is in 'FetchInfo', unless it was skipped.
+    Checked    bool
+    SkipReason string
+    crawler.FetchInfo
+}
+
+// Requests an external link to check it is alive, without reading its
+// contents. Servers not supporting HEAD requests are sent a GET instead.
+func checkExternalLink(ctx context.Context, client *http.Client, userAgent string, docId crawler.DocId) (crawler.Response, error) {
+    response, err := addHttpRequest(ctx, client, http.MethodHead, userAgent, docId)
+
+    if err == nil && (response.StatusCode == http.StatusMethodNotAllowed ||
+        response.StatusCode == http.StatusNotImplemented) {
+
+        response, err = addHttpRequest(ctx, client, http.MethodGet, userAgent, docId)
+    }
+
+    if response.Body != nil {
+        discardBody(response.Body)
+        response.Body = nil
+    }
+
+    return response, err
+}
+
+// Describes the result of checking an external link.
+func (link *ExternalLink) status() string {
+    switch {
+        case !link.Checked:
+            return "not checked"
+        case link.SkipReason != "":
+            return link.SkipReason
+        case link.Error != "":
+            return "error: " + link.Error
+        default:
+            return fmt.Sprintf("%d %s", link.StatusCode, http.StatusText(link.StatusCode))
+    }
+}
+
+func (link *ExternalLink) isBroken() bool {
+    return link.Checked && link.SkipReason == "" && (link.Error != "" || link.StatusCode >= 400)
+}
+
+// Gets every external link of the crawled pages, sorted by target, with
+// the result of its check if the external links were checked.
+func (sm *SiteMap) ExternalLinks() []ExternalLink {
+    linkedFrom := make(map [crawler.DocId] []crawler.DocId)
+
+    for _, id := range sm.DocIds() {
+        seen := make(map [crawler.DocId] bool)
+
+        for _, target := range sm.docs[id].ExternalLinks {
+            if !seen[target] {
+                linkedFrom[target] = append(linkedFrom[target], id)
+                seen[target] = true
+            }
+        }
+    }
+
+    links := make([]ExternalLink, 0, len(linkedFrom))
+    for target, sources := range linkedFrom {
+        link := ExternalLink{Target: target, LinkedFrom: sources}
+        if checked, wasChecked := sm.externalChecks[target]; wasChecked {
+            link = *checked
+            link.LinkedFrom = sources
+        }
+
+        links = append(links, link)
+    }
+
+    sort.Slice(links, func(i, j int) bool { return links[i].Target < links[j].Target })
+
+    return links
+}
+
+// Requests every external link once, with as many simultaneous requests
+// as the crawl. Links are not checked after 'ctx' is done.
+func (sm *SiteMap) checkExternalLinks(ctx context.Context) error {
+    pool, err := threadpool.NewFixed(sm.options.Concurrency)
+    if err != nil {
+        return err
+    }
+    defer pool.Stop()
+
+    var wg sync.WaitGroup
+    var mutex sync.Mutex
+
+    for _, link := range sm.ExternalLinks() {
+        if ctx.Err() != nil {
+            break
+        }
+
+        target := link.Target
+        wg.Add(1)
+
+        pool.Run(string(target), func() {
+            defer wg.Done()
+
+            checked := &ExternalLink{Target: target, Checked: true}
+
+            admitted, reason := true, ""
+            if sm.options.Crawler.Filter != nil {
+                admitted, reason = sm.options.Crawler.Filter.Admit(ctx, target)
+            }
+
+            if !admitted {
+                checked.SkipReason = reason
+            } else {
+                response, err := sm.linkChecker.Request(ctx, target)
+                checked.FetchInfo = response.FetchInfo
+                if err != nil {
+                    checked.Error = err.Error()
+                }
+            }
+
+            // Requests aborted when cancelled tell nothing about the link
+            if ctx.Err() != nil {
+                return
+            }
+
+            mutex.Lock()
+            sm.externalChecks[target] = checked
+            mutex.Unlock()
+        })
+    }
+
+    wg.Wait()
+
+    return nil
+}
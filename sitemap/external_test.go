@@ -0,0 +1,43 @@
+package sitemap
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "webCrawler/crawler"
+)
+
+func TestShouldCheckExternalLinksOnce(t *testing.T) {
+    assert := assert.New(t)
+
+    sm := testSiteMap()
+    sm.options.Concurrency = 2
+    sm.externalChecks = make(map [crawler.DocId] *ExternalLink)
+    sm.docs["http://example.com"].ExternalLinks = []crawler.DocId{"http://other.com", "http://gone.com"}
+    sm.docs["http://example.com/a"].ExternalLinks = []crawler.DocId{"http://other.com"}
+
+    requests := make(chan crawler.DocId, 4)
+    sm.linkChecker = crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        requests <- docId
+        if docId == "http://gone.com" {
+            return crawler.Response{}, errors.New("no such host")
+        }
+
+        return crawler.Response{FetchInfo: crawler.FetchInfo{StatusCode: 200}}, nil
+    })
+
+    assert.Nil(sm.checkExternalLinks(context.Background()))
+    assert.Equal(2, len(requests))
+
+    links := sm.ExternalLinks()
+    assert.Equal(2, len(links))
+    assert.Equal("no such host", links[0].Error)
+    assert.Equal(200, links[1].StatusCode)
+    assert.Equal([]crawler.DocId{"http://example.com", "http://example.com/a"}, links[1].LinkedFrom)
+
+    var out bytes.Buffer
+    assert.Nil(sm.WriteBrokenLinks(&out))
+    assert.Contains(out.String(), " - http://gone.com (error: no such host, external)\n   * http://example.com\n")
+}
@@ -0,0 +1,245 @@
+package sitemap
+
+import (
+    "encoding/xml"
+    "fmt"
+    "io"
+    "strconv"
+    "strings"
+    "webCrawler/crawler"
+)
+
+// Label of a graph node, the page title or its id when it has no contents.
+func (sm *SiteMap) nodeLabel(id crawler.DocId) string {
+    if doc, wasCrawled := sm.docs[id]; wasCrawled && docProblem(doc) == "" {
+        return strings.TrimSpace(doc.Title)
+    }
+
+    return sm.title(id)
+}
+
+// Quotes a string as a Graphviz DOT identifier.
+func dotQuote(s string) string {
+    return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// Writes the site map as a Graphviz DOT directed graph, labelling every
+// document node with its title. When there are several starting points,
+// the documents reached from each of them are grouped in a cluster.
+func writeDot(sm *SiteMap, w io.Writer) error {
+    ew := &errWriter{w: w}
+
+    ew.printf("digraph sitemap {\n")
+    ew.printf("    node [shape=box];\n")
+
+    orphans := sm.orphanSet()
+
+    writeNode := func(indent string, id crawler.DocId) {
+        attrs := "label=" + dotQuote(sm.nodeLabel(id))
+        if doc, wasCrawled := sm.docs[id]; !wasCrawled || docProblem(doc) != "" {
+            attrs += ", color=red"
+        }
+
+        // A node has a single style attribute listing all its styles
+        var styles []string
+        if sm.isRoot(id) {
+            styles = append(styles, "bold")
+        }
+        if orphans[id] {
+            styles = append(styles, "dashed")
+        }
+        if len(styles) > 0 {
+            attrs += ", style=" + dotQuote(strings.Join(styles, ","))
+        }
+
+        if doc, wasCrawled := sm.docs[id]; wasCrawled && len(doc.Metadata) > 0 {
+            attrs += ", tooltip=" + dotQuote(strings.Join(metadataLines(doc), "\n"))
+        }
+
+        ew.printf("%s%s [%s];\n", indent, dotQuote(string(id)), attrs)
+    }
+
+    grouped := make(map [crawler.DocId] bool)
+    if len(sm.roots) > 1 {
+        for i, ids := range sm.DocIdsBySeed() {
+            ew.printf("    subgraph %s {\n", dotQuote(fmt.Sprintf("cluster_%d", i)))
+            ew.printf("        label=%s;\n", dotQuote(string(sm.roots[i])))
+
+            for _, id := range ids {
+                writeNode("        ", id)
+                grouped[id] = true
+            }
+
+            ew.printf("    }\n")
+        }
+    }
+
+    for _, id := range sm.NodeIds() {
+        if !grouped[id] {
+            writeNode("    ", id)
+        }
+    }
+
+    for _, id := range sm.DocIds() {
+        for _, link := range sm.docs[id].Links {
+            ew.printf("    %s -> %s;\n", dotQuote(string(id)), dotQuote(string(link)))
+        }
+    }
+
+    ew.printf("}\n")
+
+    return ew.err
+}
+
+type graphMlKey struct {
+    Id       string `xml:"id,attr"`
+    For      string `xml:"for,attr"`
+    AttrName string `xml:"attr.name,attr"`
+    AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMlData struct {
+    Key   string `xml:"key,attr"`
+    Value string `xml:",chardata"`
+}
+
+type graphMlNode struct {
+    Id   string        `xml:"id,attr"`
+    Data []graphMlData `xml:"data"`
+}
+
+type graphMlEdge struct {
+    Source string        `xml:"source,attr"`
+    Target string        `xml:"target,attr"`
+    Data   []graphMlData `xml:"data"`
+}
+
+type graphMlGraph struct {
+    Id          string        `xml:"id,attr"`
+    EdgeDefault string        `xml:"edgedefault,attr"`
+    Nodes       []graphMlNode `xml:"node"`
+    Edges       []graphMlEdge `xml:"edge"`
+}
+
+type graphMl struct {
+    XMLName xml.Name     `xml:"http://graphml.graphdrawing.org/xmlns graphml"`
+    Keys    []graphMlKey `xml:"key"`
+    Graph   graphMlGraph `xml:"graph"`
+}
+
+// Adds a data element to the node, unless its value is empty.
+func (node *graphMlNode) add(key string, value string) {
+    if value != "" {
+        node.Data = append(node.Data, graphMlData{key, value})
+    }
+}
+
+// Adds a data element to the edge, unless its value is empty.
+func (edge *graphMlEdge) add(key string, value string) {
+    if value != "" {
+        edge.Data = append(edge.Data, graphMlData{key, value})
+    }
+}
+
+// Writes the site map as a GraphML directed graph, with the title and
+// request metadata of every document as node data.
+func writeGraphMl(sm *SiteMap, w io.Writer) error {
+    out := graphMl{
+        Keys: []graphMlKey{
+            {"title", "node", "title", "string"},
+            {"depth", "node", "depth", "int"},
+            {"status", "node", "status", "int"},
+            {"contentType", "node", "contentType", "string"},
+            {"error", "node", "error", "string"},
+            {"skipReason", "node", "skipReason", "string"},
+            {"seed", "node", "seed", "string"},
+            {"canonical", "node", "canonical", "string"},
+            {"noindex", "node", "noindex", "boolean"},
+            {"nofollow", "node", "nofollow", "boolean"},
+            {"listed", "node", "listed", "boolean"},
+            {"text", "edge", "text", "string"},
+            {"linkTitle", "edge", "title", "string"},
+            {"rel", "edge", "rel", "string"},
+            {"position", "edge", "position", "int"},
+            {"orphan", "node", "orphan", "boolean"},
+        },
+        Graph: graphMlGraph{Id: "sitemap", EdgeDefault: "directed"},
+    }
+
+    // Every metadata key found gets its own GraphML key
+    metadataKeySet := make(map [string] bool)
+    for _, doc := range sm.docs {
+        for key := range doc.Metadata {
+            metadataKeySet[key] = true
+        }
+    }
+    for _, key := range sortedKeys(metadataKeySet) {
+        out.Keys = append(out.Keys, graphMlKey{"metadata:" + key, "node", "metadata:" + key, "string"})
+    }
+
+    orphans := sm.orphanSet()
+
+    for _, id := range sm.NodeIds() {
+        node := graphMlNode{Id: string(id)}
+
+        if doc, wasCrawled := sm.docs[id]; wasCrawled {
+            node.add("title", strings.TrimSpace(doc.Title))
+            node.add("depth", strconv.Itoa(doc.Depth))
+            if doc.StatusCode != 0 {
+                node.add("status", strconv.Itoa(doc.StatusCode))
+            }
+            node.add("contentType", doc.ContentType)
+            node.add("error", doc.Error)
+            node.add("skipReason", doc.SkipReason)
+            node.add("seed", string(doc.Seed))
+            node.add("canonical", string(doc.Canonical))
+            if doc.NoIndex {
+                node.add("noindex", "true")
+            }
+            if doc.NoFollow {
+                node.add("nofollow", "true")
+            }
+            for _, key := range metadataKeys(doc) {
+                node.add("metadata:" + key, strings.Join(doc.Metadata[key], "\n"))
+            }
+        }
+
+        if sm.listed[id] {
+            node.add("listed", "true")
+        }
+        if orphans[id] {
+            node.add("orphan", "true")
+        }
+
+        out.Graph.Nodes = append(out.Graph.Nodes, node)
+    }
+
+    for _, id := range sm.DocIds() {
+        for i, link := range sm.docs[id].Links {
+            details := linkDetail(sm.docs[id], i)
+
+            edge := graphMlEdge{Source: string(id), Target: string(link)}
+            edge.add("text", details.Text)
+            edge.add("linkTitle", details.Title)
+            edge.add("rel", strings.Join(details.Rel, " "))
+            if details.Position > 0 {
+                edge.add("position", strconv.Itoa(details.Position))
+            }
+
+            out.Graph.Edges = append(out.Graph.Edges, edge)
+        }
+    }
+
+    if _, err := io.WriteString(w, xml.Header); err != nil {
+        return err
+    }
+
+    encoder := xml.NewEncoder(w)
+    encoder.Indent("", "  ")
+    if err := encoder.Encode(out); err != nil {
+        return err
+    }
+
+    _, err := io.WriteString(w, "\n")
+    return err
+}
@@ -0,0 +1,153 @@
+package sitemap
+
+import (
+    "encoding/json"
+    "io"
+    "strings"
+    "webCrawler/crawler"
+)
+
+type jsonResult struct {
+    StopReason   string         `json:"stopReason"`
+    Limit        string         `json:"limit,omitempty"`
+    DocsCrawled  int            `json:"docsCrawled"`
+    LinksSkipped map[string]int `json:"linksSkipped,omitempty"`
+}
+
+type jsonNode struct {
+    Id            string   `json:"id"`
+    Title         string   `json:"title,omitempty"`
+    Crawled       bool     `json:"crawled"`
+    Depth         int      `json:"depth"`
+    StatusCode    int      `json:"statusCode,omitempty"`
+    ContentType   string   `json:"contentType,omitempty"`
+    RedirectChain []string `json:"redirectChain,omitempty"`
+    Error         string   `json:"error,omitempty"`
+    SkipReason    string   `json:"skipReason,omitempty"`
+    Seed          string   `json:"seed,omitempty"`
+    Canonical     string   `json:"canonical,omitempty"`
+    Aliases       []string `json:"aliases,omitempty"`
+    Metadata      map [string] []string `json:"metadata,omitempty"`
+    NoIndex       bool     `json:"noindex,omitempty"`
+    NoFollow      bool     `json:"nofollow,omitempty"`
+    Listed        bool     `json:"listed,omitempty"`
+    Orphan        bool     `json:"orphan,omitempty"`
+    Links         []string `json:"links"`
+    LinkDetails   []jsonLink `json:"linkDetails,omitempty"`
+    ExternalLinks []string `json:"externalLinks,omitempty"`
+}
+
+type jsonLink struct {
+    Target   string   `json:"target"`
+    Text     string   `json:"text,omitempty"`
+    Title    string   `json:"title,omitempty"`
+    Rel      []string `json:"rel,omitempty"`
+    Position int      `json:"position,omitempty"`
+}
+
+type jsonExternalLink struct {
+    Target     string   `json:"target"`
+    Checked    bool     `json:"checked"`
+    StatusCode int      `json:"statusCode,omitempty"`
+    Error      string   `json:"error,omitempty"`
+    SkipReason string   `json:"skipReason,omitempty"`
+    LinkedFrom []string `json:"linkedFrom"`
+}
+
+type jsonSiteMap struct {
+    Roots         []string           `json:"roots"`
+    Result        jsonResult         `json:"result"`
+    Nodes         []jsonNode         `json:"nodes"`
+    ExternalLinks []jsonExternalLink `json:"externalLinks"`
+}
+
+func docIdsToStrings(ids []crawler.DocId) []string {
+    strs := make([]string, len(ids))
+    for i, id := range ids {
+        strs[i] = string(id)
+    }
+
+    return strs
+}
+
+// Writes the site map as a JSON object with the crawl result and a node
+// for every document, including the ones linked but not crawled. Crawled
+// documents name the starting point they were reached from.
+func writeJson(sm *SiteMap, w io.Writer) error {
+    out := jsonSiteMap{
+        Roots: docIdsToStrings(sm.roots),
+        Result: jsonResult{
+            StopReason: sm.result.StopReason.String(),
+            DocsCrawled: sm.result.DocsCrawled,
+            LinksSkipped: make(map[string]int),
+        },
+        Nodes: []jsonNode{},
+        ExternalLinks: []jsonExternalLink{},
+    }
+
+    if sm.result.StopReason == crawler.LimitReached {
+        out.Result.Limit = sm.result.Limit.String()
+    }
+
+    for limit, skipped := range sm.result.LinksSkipped {
+        out.Result.LinksSkipped[limit.String()] = skipped
+    }
+
+    orphans := sm.orphanSet()
+
+    for _, id := range sm.NodeIds() {
+        node := jsonNode{
+            Id: string(id),
+            Listed: sm.listed[id],
+            Orphan: orphans[id],
+            Links: []string{},
+        }
+
+        if doc, wasCrawled := sm.docs[id]; wasCrawled {
+            node.Title = strings.TrimSpace(doc.Title)
+            node.Crawled = doc.SkipReason == ""
+            node.Depth = doc.Depth
+            node.StatusCode = doc.StatusCode
+            node.ContentType = doc.ContentType
+            node.RedirectChain = docIdsToStrings(doc.RedirectChain)
+            node.Error = doc.Error
+            node.SkipReason = doc.SkipReason
+            node.Seed = string(doc.Seed)
+            node.Canonical = string(doc.Canonical)
+            node.Aliases = docIdsToStrings(doc.Aliases)
+            node.Metadata = doc.Metadata
+            node.NoIndex = doc.NoIndex
+            node.NoFollow = doc.NoFollow
+            node.Links = docIdsToStrings(doc.Links)
+            for i := range doc.Links {
+                link := linkDetail(doc, i)
+                node.LinkDetails = append(node.LinkDetails, jsonLink{
+                    string(link.Target),
+                    link.Text,
+                    link.Title,
+                    link.Rel,
+                    link.Position,
+                })
+            }
+            node.ExternalLinks = docIdsToStrings(doc.ExternalLinks)
+        }
+
+        out.Nodes = append(out.Nodes, node)
+    }
+
+    for _, link := range sm.ExternalLinks() {
+        out.ExternalLinks = append(out.ExternalLinks, jsonExternalLink{
+            Target: string(link.Target),
+            Checked: link.Checked,
+            StatusCode: link.StatusCode,
+            Error: link.Error,
+            SkipReason: link.SkipReason,
+            LinkedFrom: docIdsToStrings(link.LinkedFrom),
+        })
+    }
+
+    encoder := json.NewEncoder(w)
+    encoder.SetIndent("", "  ")
+
+    return encoder.Encode(out)
+}
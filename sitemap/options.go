@@ -0,0 +1,81 @@
+package sitemap
+
+import (
+    "go.uber.org/zap"
+    "time"
+    "webCrawler/crawler"
+    "webCrawler/htmlscanner"
+    "webCrawler/normalize"
+    "webCrawler/politeness"
+    "webCrawler/scope"
+)
+
+type Options struct {
+    // Number of documents requested simultaneously.
+    Concurrency int
+
+    // Size of the buffer receiving the crawled documents.
+    DocOutputChSize int
+
+    // Time limit for a single document request. Zero means no limit.
+    RequestTimeout time.Duration
+
+    // User agent sent on requests, and used to select the robots.txt rules.
+    UserAgent string
+
+    // Skip the documents disallowed by the robots.txt file of their host.
+    RespectRobots bool
+
+    // Also crawl the pages listed in the sitemaps of the starting point host,
+    // found through its robots.txt file and at '/sitemap.xml'. The listed
+    // pages no other page links to are reported as orphans.
+    SeedFromSitemaps bool
+
+    // Request every link to a document out of the crawl scope once, without
+    // crawling it, to check whether it is still alive.
+    CheckExternalLinks bool
+
+    Scope scope.Policy
+
+    // Normalization of the document URLs, deciding which URLs refer to the
+    // same document.
+    Normalizer normalize.Normalizer
+
+    // Look for the links built by the scripts of the pages, e.g. of single
+    // page apps, in the URLs and routes found in their scripts. Only used
+    // when 'Crawler.Renderer' is nil.
+    RenderScripts bool
+
+    Politeness politeness.Options
+    Crawler crawler.Options
+    Scanner htmlscanner.Options
+
+    // Scanners of the documents of other types than HTML, by media type,
+    // e.g. "application/rss+xml", or by extension, e.g. ".md". They replace
+    // the default scanners, which skip images and other binary documents.
+    Scanners map [string] crawler.Scanner
+
+    Logger *zap.Logger
+}
+
+func DefaultOptions() Options {
+    logger, _ := zap.NewProduction()
+
+    return Options {
+        Concurrency: 6,
+        DocOutputChSize: 1024,
+        RequestTimeout: 30 * time.Second,
+        UserAgent: "webCrawler/1.0",
+        RespectRobots: true,
+        SeedFromSitemaps: false,
+        CheckExternalLinks: false,
+        Scope: scope.DefaultPolicy(),
+        Normalizer: normalize.Default(),
+        RenderScripts: false,
+        Politeness: politeness.DefaultOptions(),
+        Crawler: crawler.DefaultOptions(),
+        Scanner: htmlscanner.DefaultOptions(),
+        Scanners: nil,
+        Logger: logger,
+    }
+}
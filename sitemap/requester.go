@@ -0,0 +1,167 @@
+package sitemap
+
+import (
+    "context"
+    "errors"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+    "webCrawler/crawler"
+    "webCrawler/politeness"
+    "webCrawler/robots"
+)
+
+const blockedByRobots = "blocked by robots"
+
+// Filter skipping the documents disallowed by the robots.txt file of their host.
+func robotsFilter(cache *robots.Cache) crawler.Filter {
+    return crawler.FilterFunc(func(ctx context.Context, docId crawler.DocId) (bool, string) {
+        docUrl, err := url.Parse(string(docId))
+        if err != nil {
+            return true, ""
+        }
+
+        if !cache.Allowed(ctx, docUrl) {
+            return false, blockedByRobots
+        }
+
+        return true, ""
+    })
+}
+
+// Gets the delay between requests asked by the robots.txt file of the host.
+func robotsCrawlDelay(cache *robots.Cache) func(ctx context.Context, docId crawler.DocId) time.Duration {
+    return func(ctx context.Context, docId crawler.DocId) time.Duration {
+        docUrl, err := url.Parse(string(docId))
+        if err != nil {
+            return 0
+        }
+
+        return cache.Rules(ctx, docUrl).CrawlDelay()
+    }
+}
+
+// Maximum number of redirects followed for a single request.
+const maxRedirects = 10
+
+// Requests a document through HTTP with 'method'. Only successful responses
+// have a body to be scanned, while error pages just report their status.
+func addHttpRequest(ctx context.Context, client *http.Client, method string, userAgent string, requestedUrlStr crawler.DocId) (crawler.Response, error) {
+    var response crawler.Response
+
+    requestedUrl, err := url.Parse(string(requestedUrlStr))
+    if err != nil {
+        return response, err
+    }
+
+    if !requestedUrl.IsAbs() {
+        return response, errors.New("URL to request should be absolute")
+    }
+
+    req, err := http.NewRequestWithContext(ctx, method, requestedUrl.String(), nil)
+    if err != nil {
+        return response, err
+    }
+
+    req.Header.Set("User-Agent", userAgent)
+
+    // Record every redirect instead of silently following them
+    redirectingClient := *client
+    redirectingClient.CheckRedirect = func(redirectReq *http.Request, via []*http.Request) error {
+        if len(via) >= maxRedirects {
+            return errors.New("stopped after " + strconv.Itoa(maxRedirects) + " redirects")
+        }
+
+        response.RedirectChain = append(response.RedirectChain, crawler.DocId(redirectReq.URL.String()))
+        return nil
+    }
+
+    resp, err := redirectingClient.Do(req)
+    if resp != nil {
+        response.StatusCode = resp.StatusCode
+        response.ContentType = resp.Header.Get("Content-Type")
+        response.LastModified, _ = http.ParseTime(resp.Header.Get("Last-Modified"))
+        response.RobotsTag = robotsTagDirectives(resp.Header.Values("X-Robots-Tag"))
+    }
+    if err != nil {
+        return response, err
+    }
+
+    if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+        discardBody(resp.Body)
+        return response, &politeness.ThrottledError{
+            StatusCode: resp.StatusCode,
+            RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+        }
+    }
+
+    if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        discardBody(resp.Body)
+        return response, nil
+    }
+
+    response.Body = resp.Body
+
+    return response, nil
+}
+
+// Directives of X-Robots-Tag header values that may be prefixed by a user
+// agent, e.g. "otherbot: noindex", which applies to the rest of the value.
+var robotsTagDirectivesWithValue = map [string] bool {
+    "unavailable_after": true,
+    "max-snippet": true,
+    "max-image-preview": true,
+    "max-video-preview": true,
+}
+
+// Gets the lower case directives of the X-Robots-Tag header values applying
+// to every crawler, skipping those given for a specific user agent.
+func robotsTagDirectives(values []string) []string {
+    var directives []string
+
+    for _, value := range values {
+        forEveryAgent := true
+
+        for _, directive := range strings.Split(strings.ToLower(value), ",") {
+            directive = strings.TrimSpace(directive)
+
+            if colon := strings.Index(directive, ":"); colon >= 0 {
+                if name := strings.TrimSpace(directive[:colon]); !robotsTagDirectivesWithValue[name] {
+                    forEveryAgent = false
+                    directive = strings.TrimSpace(directive[colon+1:])
+                }
+            }
+
+            if forEveryAgent && directive != "" {
+                directives = append(directives, directive)
+            }
+        }
+    }
+
+    return directives
+}
+
+// Reads what's left of a response body, up to a limit, and closes it so
+// the connection can be reused.
+func discardBody(body io.ReadCloser) {
+    _, _ = io.Copy(ioutil.Discard, io.LimitReader(body, 64 * 1024))
+    body.Close()
+}
+
+// Parses the value of a 'Retry-After' header, either a number of seconds
+// or an HTTP date. Returns zero when missing or not valid.
+func parseRetryAfter(value string) time.Duration {
+    if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+        return time.Duration(seconds) * time.Second
+    }
+
+    if date, err := http.ParseTime(value); err == nil {
+        return time.Until(date)
+    }
+
+    return 0
+}
@@ -0,0 +1,19 @@
+package sitemap
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+)
+
+func TestShouldParseRobotsTagDirectives(t *testing.T) {
+    assert := assert.New(t)
+
+    assert.Equal([]string{"noindex", "unavailable_after: 25 jun 2030 15:00:00 pst", "nofollow"},
+        robotsTagDirectives([]string{
+            "NoIndex, unavailable_after: 25 Jun 2030 15:00:00 PST",
+            "otherbot: noarchive, nosnippet",
+            "nofollow",
+        }))
+
+    assert.Empty(robotsTagDirectives(nil))
+}
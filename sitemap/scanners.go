@@ -0,0 +1,74 @@
+package sitemap
+
+import (
+    "webCrawler/crawler"
+    "webCrawler/cssscanner"
+    "webCrawler/feedscanner"
+    "webCrawler/htmlscanner"
+    "webCrawler/pdfscanner"
+)
+
+// Media types and extensions of the HTML documents.
+var htmlTypes = []string{"text/html", "application/xhtml+xml", ".html", ".htm"}
+
+// Media types and extensions of the RSS and Atom feeds. Feeds are often
+// served as generic XML documents.
+var feedTypes = []string{
+    "application/rss+xml", "application/atom+xml", "application/rdf+xml",
+    "application/xml", "text/xml", ".rss", ".atom", ".xml",
+}
+
+// Media types and extensions of the documents recorded without scanning
+// them, as they have no links. Documents served as generic binaries, e.g.
+// "application/octet-stream", are still scanned when their extension is
+// known, e.g. ".pdf".
+var unscannedTypes = []string{
+    "image/*", "audio/*", "video/*", "font/*",
+    "application/zip", "application/gzip", "application/octet-stream",
+    "application/json", "application/javascript", "text/javascript",
+    ".png", ".jpg", ".jpeg", ".gif", ".webp", ".svg", ".ico", ".zip", ".gz",
+    ".mp3", ".mp4", ".webm", ".woff", ".woff2", ".ttf", ".json", ".js",
+}
+
+// Builds the scanner of the crawled documents, choosing the scanner of each
+// document by its type. Documents of unknown type are scanned as HTML.
+func newScanner(options Options) crawler.Scanner {
+    html := htmlscanner.New(options.Scanner)
+    registry := crawler.NewScannerRegistry(html)
+
+    for _, key := range htmlTypes {
+        registry.Register(key, html)
+    }
+
+    feeds := feedscanner.New(feedscanner.Options{
+        LinksPerMsg: options.Scanner.LinksPerMsg,
+        Logger: options.Scanner.Logger,
+    })
+    for _, key := range feedTypes {
+        registry.Register(key, feeds)
+    }
+
+    pdfOptions := pdfscanner.DefaultOptions()
+    pdfOptions.LinksPerMsg = options.Scanner.LinksPerMsg
+    pdfOptions.Logger = options.Scanner.Logger
+    pdfs := pdfscanner.New(pdfOptions)
+    registry.Register("application/pdf", pdfs)
+    registry.Register(".pdf", pdfs)
+
+    cssOptions := cssscanner.DefaultOptions()
+    cssOptions.LinksPerMsg = options.Scanner.LinksPerMsg
+    cssOptions.Logger = options.Scanner.Logger
+    stylesheets := cssscanner.New(cssOptions)
+    registry.Register("text/css", stylesheets)
+    registry.Register(".css", stylesheets)
+
+    for _, key := range unscannedTypes {
+        registry.Register(key, crawler.NopScanner())
+    }
+
+    for key, scanner := range options.Scanners {
+        registry.Register(key, scanner)
+    }
+
+    return registry
+}
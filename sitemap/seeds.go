@@ -0,0 +1,202 @@
+package sitemap
+
+import (
+    "bufio"
+    "compress/gzip"
+    "context"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "go.uber.org/zap"
+    "io"
+    "net/url"
+    "sort"
+    "strings"
+    "webCrawler/crawler"
+)
+
+// Maximum number of sitemap files read when looking for seeds, bounding the
+// nested sitemap indexes followed.
+const maxSeedSitemaps = 100
+
+type xmlSitemapLoc struct {
+    Loc string `xml:"loc"`
+}
+
+// Either a 'urlset' listing pages or a 'sitemapindex' listing other sitemaps.
+type xmlSitemapDoc struct {
+    XMLName  xml.Name
+    Urls     []xmlSitemapLoc `xml:"url"`
+    Sitemaps []xmlSitemapLoc `xml:"sitemap"`
+}
+
+// Parses a sitemaps.org XML document, compressed with gzip or not. Returns
+// the pages listed by a 'urlset', or the sitemaps listed by a 'sitemapindex'.
+func parseXmlSitemap(r io.Reader) (pages []string, sitemaps []string, err error) {
+    buffered := bufio.NewReader(r)
+
+    var in io.Reader = buffered
+    if magic, _ := buffered.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+        gzipIn, err := gzip.NewReader(buffered)
+        if err != nil {
+            return nil, nil, err
+        }
+        defer gzipIn.Close()
+        in = gzipIn
+    }
+
+    var doc xmlSitemapDoc
+    if err := xml.NewDecoder(io.LimitReader(in, maxBytesPerXmlSitemap)).Decode(&doc); err != nil {
+        return nil, nil, err
+    }
+
+    switch doc.XMLName.Local {
+        case "urlset":
+            for _, entry := range doc.Urls {
+                pages = append(pages, strings.TrimSpace(entry.Loc))
+            }
+        case "sitemapindex":
+            for _, entry := range doc.Sitemaps {
+                sitemaps = append(sitemaps, strings.TrimSpace(entry.Loc))
+            }
+        default:
+            return nil, nil, errors.New("unknown sitemap root element '" + doc.XMLName.Local + "'")
+    }
+
+    return pages, sitemaps, nil
+}
+
+// Gets the pages listed by the sitemaps of the host of 'rootUrl' that are in
+// the crawl scope, sorted. The sitemaps are the ones named by the robots.txt
+// file of the host and the one at '/sitemap.xml'.
+func (sm *SiteMap) sitemapSeeds(ctx context.Context, rootUrl *url.URL) []crawler.DocId {
+    origin := rootUrl.Scheme + "://" + rootUrl.Host
+
+    pending := append([]string{}, sm.robots.Rules(ctx, rootUrl).Sitemaps()...)
+    pending = append(pending, origin + "/sitemap.xml")
+
+    visited := make(map [string] bool)
+    seeds := make(map [crawler.DocId] bool)
+
+    for len(pending) > 0 && len(visited) < maxSeedSitemaps && ctx.Err() == nil {
+        sitemapUrl := pending[0]
+        pending = pending[1:]
+
+        if visited[sitemapUrl] {
+            continue
+        }
+        visited[sitemapUrl] = true
+
+        pages, sitemaps, err := sm.fetchXmlSitemap(ctx, sitemapUrl)
+        if err != nil {
+            sm.options.Logger.Info("Sitemap not read",
+                zap.String("URL", sitemapUrl), zap.Error(err))
+            continue
+        }
+
+        pending = append(pending, sitemaps...)
+
+        for _, page := range pages {
+            pageUrl, err := url.ParseRequestURI(page)
+            if err != nil || !sm.scope.Allows(pageUrl, rootUrl) {
+                continue
+            }
+
+            seeds[idFromAbsUrl(pageUrl, sm.options.Normalizer)] = true
+        }
+    }
+
+    ids := make([]crawler.DocId, 0, len(seeds))
+    for id := range seeds {
+        ids = append(ids, id)
+    }
+
+    sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+    sm.options.Logger.Info("Seeds read from sitemaps",
+        zap.Int("Sitemaps", len(visited)), zap.Int("Seeds", len(ids)))
+
+    return ids
+}
+
+// Fetches a sitemap like any crawled document, respecting the robots.txt
+// rules and the request rate limits of its host.
+func (sm *SiteMap) fetchXmlSitemap(ctx context.Context, sitemapUrl string) (pages []string, sitemaps []string, err error) {
+    docId := crawler.DocId(sitemapUrl)
+
+    if sm.options.Crawler.Filter != nil {
+        if admitted, reason := sm.options.Crawler.Filter.Admit(ctx, docId); !admitted {
+            return nil, nil, errors.New(reason)
+        }
+    }
+
+    resp, err := sm.requester.Request(ctx, docId)
+    if err != nil {
+        if resp.Body != nil {
+            resp.Body.Close()
+        }
+        return nil, nil, err
+    }
+
+    // Only successful responses have a body
+    if resp.Body == nil {
+        return nil, nil, fmt.Errorf("status %d", resp.StatusCode)
+    }
+    defer resp.Body.Close()
+
+    return parseXmlSitemap(resp.Body)
+}
+
+// Gets the pages listed in the sitemaps of the site that no other crawled
+// page links to, sorted. The starting points are never orphans. Only the
+// links of the crawled pages are known, so a crawl stopped by a limit may
+// report more orphans than there are.
+func (sm *SiteMap) Orphans() []crawler.DocId {
+    var orphans []crawler.DocId
+    for id := range sm.orphanSet() {
+        orphans = append(orphans, id)
+    }
+
+    sort.Slice(orphans, func(i, j int) bool { return orphans[i] < orphans[j] })
+
+    return orphans
+}
+
+func (sm *SiteMap) orphanSet() map [crawler.DocId] bool {
+    linked := make(map [crawler.DocId] bool)
+
+    for _, doc := range sm.docs {
+        for _, link := range doc.Links {
+            if link == doc.DocId {
+                continue
+            }
+
+            // A link to a redirect links to its final page as well
+            linked[link] = true
+            if target, wasCrawled := sm.docs[link]; wasCrawled {
+                linked[target.FinalId(link)] = true
+            }
+        }
+    }
+
+    // Listed pages merged into another one are that one
+    primaryOf := make(map [crawler.DocId] crawler.DocId)
+    for _, doc := range sm.docs {
+        for _, alias := range doc.Aliases {
+            primaryOf[alias] = doc.DocId
+        }
+    }
+
+    orphans := make(map [crawler.DocId] bool)
+    for id := range sm.listed {
+        if primary, isAlias := primaryOf[id]; isAlias {
+            id = primary
+        }
+
+        if !sm.isRoot(id) && !linked[id] {
+            orphans[id] = true
+        }
+    }
+
+    return orphans
+}
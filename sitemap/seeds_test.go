@@ -0,0 +1,106 @@
+package sitemap
+
+import (
+    "bytes"
+    "compress/gzip"
+    "context"
+    "github.com/stretchr/testify/assert"
+    "go.uber.org/zap"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "strings"
+    "sync"
+    "testing"
+    "webCrawler/crawler"
+)
+
+func TestShouldParseXmlSitemaps(t *testing.T) {
+    assert := assert.New(t)
+
+    pages, sitemaps, err := parseXmlSitemap(strings.NewReader(
+        `<?xml version="1.0" encoding="UTF-8"?>` +
+        `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+        `<url><loc> http://example.com/a </loc><priority>0.5</priority></url>` +
+        `<url><loc>http://example.com/b</loc></url>` +
+        `</urlset>`))
+
+    assert.Nil(err)
+    assert.Equal([]string{"http://example.com/a", "http://example.com/b"}, pages)
+    assert.Empty(sitemaps)
+
+    var compressed bytes.Buffer
+    gzipOut := gzip.NewWriter(&compressed)
+    gzipOut.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">` +
+        `<sitemap><loc>http://example.com/sitemap-1.xml.gz</loc></sitemap>` +
+        `</sitemapindex>`))
+    gzipOut.Close()
+
+    pages, sitemaps, err = parseXmlSitemap(&compressed)
+
+    assert.Nil(err)
+    assert.Empty(pages)
+    assert.Equal([]string{"http://example.com/sitemap-1.xml.gz"}, sitemaps)
+
+    _, _, err = parseXmlSitemap(strings.NewReader("<html></html>"))
+    assert.NotNil(err)
+}
+
+func TestShouldFindOrphans(t *testing.T) {
+    assert := assert.New(t)
+
+    sm := testSiteMap()
+
+    orphan := crawler.DefaultDocInfo("http://example.com/orphan")
+    orphan.StatusCode = 200
+    orphan.Links = []crawler.DocId{"http://example.com/orphan", "http://example.com/a"}
+    sm.docs[orphan.DocId] = orphan
+
+    sm.listed = map [crawler.DocId] bool{
+        "http://example.com": true,
+        "http://example.com/a": true,
+        "http://example.com/orphan": true,
+    }
+
+    assert.Equal([]crawler.DocId{"http://example.com/orphan"}, sm.Orphans())
+}
+
+func TestShouldRequestSitemapsPolitely(t *testing.T) {
+    assert := assert.New(t)
+
+    var mutex sync.Mutex
+    requested := make(map [string] string)
+
+    var server *httptest.Server
+    server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        mutex.Lock()
+        requested[r.URL.Path] = r.Header.Get("User-Agent")
+        mutex.Unlock()
+
+        switch r.URL.Path {
+            case "/robots.txt":
+                io.WriteString(w, "User-agent: *\nDisallow: /private\nSitemap: " + server.URL + "/private.xml\n")
+            case "/sitemap.xml":
+                io.WriteString(w, `<urlset><url><loc>` + server.URL + `/a</loc></url></urlset>`)
+            default:
+                http.NotFound(w, r)
+        }
+    }))
+    defer server.Close()
+
+    options := DefaultOptions()
+    options.UserAgent = "testCrawler/1.0"
+    options.Politeness.RequestsPerSecond = 0
+    options.Logger = zap.NewNop()
+
+    sm, err := NewSiteMap(options)
+    assert.Nil(err)
+
+    rootUrl, _ := url.Parse(server.URL)
+    seeds := sm.sitemapSeeds(context.Background(), rootUrl)
+
+    assert.Equal([]crawler.DocId{crawler.DocId(server.URL + "/a")}, seeds)
+    assert.NotContains(requested, "/private.xml", "Expected sitemaps disallowed by robots.txt not to be requested")
+    assert.Equal("testCrawler/1.0", requested["/sitemap.xml"])
+}
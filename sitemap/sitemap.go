@@ -1,76 +1,162 @@
 package sitemap
 
 import (
+    "context"
     "errors"
     "fmt"
-    "io"
     "net/http"
     "net/url"
+    "sort"
     "strings"
     "webCrawler/crawler"
-    "webCrawler/htmlscanner"
+    "webCrawler/politeness"
+    "webCrawler/robots"
+    "webCrawler/scope"
+    "webCrawler/scriptrenderer"
     "webCrawler/threadpool"
 )
 
-const numberOfConcurrentConnections = 6
-const docOutputChSize = 1024
-
 type SiteMap struct {
     crawler crawler.Crawler
     docs map [crawler.DocId] *crawler.DocInfo
-    root crawler.DocId
+    roots []crawler.DocId
+    result crawler.Result
+    // Pages listed in the sitemaps of the site, when used as seeds
+    listed map [crawler.DocId] bool
+    // Requester of the sitemaps of the site, as polite as the crawl
+    requester crawler.Requester
+    robots *robots.Cache
+    scope *scope.Resolver
+    // Requester checking the external links, and the results of the checks
+    linkChecker crawler.Requester
+    externalChecks map [crawler.DocId] *ExternalLink
+    options Options
 }
 
-func NewSiteMap() *SiteMap {
+func NewSiteMap(options Options) (*SiteMap, error) {
+
+    resolver, err := scope.NewResolver(urlResolver{options.Normalizer}, options.Scope)
+    if err != nil {
+        return nil, err
+    }
 
-    pool, err := threadpool.NewFixed(numberOfConcurrentConnections)
+    pool, err := threadpool.NewFixed(options.Concurrency)
     if err != nil {
-        return nil
+        return nil, err
+    }
+
+    if options.DocOutputChSize < 0 {
+        return nil, errors.New("document output buffer size should not be negative")
+    }
+
+    options.Crawler.Logger = options.Logger
+    options.Scanner.Logger = options.Logger
+    options.Scanner.UserAgent = options.UserAgent
+
+    client := &http.Client{
+        Timeout: options.RequestTimeout,
+    }
+
+    requester := crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        return addHttpRequest(ctx, client, http.MethodGet, options.UserAgent, docId)
+    })
+
+    linkChecker := crawler.RequesterFunc(func(ctx context.Context, docId crawler.DocId) (crawler.Response, error) {
+        return checkExternalLink(ctx, client, options.UserAgent, docId)
+    })
+
+    options.Politeness.Logger = options.Logger
+
+    // Also used to discover the sitemaps of the site
+    robotsCache := robots.NewCache(client, options.UserAgent, options.Logger)
+
+    if options.RespectRobots {
+        options.Crawler.Filter = robotsFilter(robotsCache)
+        options.Politeness.CrawlDelay = robotsCrawlDelay(robotsCache)
+    }
+
+    docRequester := politeness.NewRequester(requester, options.Politeness)
+
+    if options.RenderScripts && options.Crawler.Renderer == nil {
+        rendererOptions := scriptrenderer.DefaultOptions()
+        rendererOptions.Filter = options.Crawler.Filter
+        rendererOptions.Logger = options.Logger
+        options.Crawler.Renderer = scriptrenderer.New(docRequester, rendererOptions)
     }
 
     return &SiteMap{
         crawler.New(
-            htmlscanner.New(),
-            crawler.RequesterFunc(addHttpRequest),
-            crawler.ResolverFunc(idFromLocator),
+            newScanner(options),
+            docRequester,
+            resolver,
             pool,
+            options.Crawler,
         ),
         make(map [crawler.DocId] *crawler.DocInfo),
-        "",
-    }
+        nil,
+        crawler.Result{},
+        make(map [crawler.DocId] bool),
+        docRequester,
+        robotsCache,
+        resolver,
+        politeness.NewRequester(linkChecker, options.Politeness),
+        make(map [crawler.DocId] *ExternalLink),
+        options,
+    }, nil
 }
 
-func addHttpRequest(requestedUrlStr crawler.DocId) (io.ReadCloser, error) {
-    var emptyReadCloser io.ReadCloser
+// Crawls the websites reachable from every starting point in a single run.
+// Documents reachable from several starting points are only crawled once.
+func (sm *SiteMap) ProduceFrom(startingPoints ...string) error {
+    return sm.ProduceFromContext(context.Background(), startingPoints...)
+}
 
-    requestedUrl, err := url.Parse(string(requestedUrlStr))
-    if err != nil {
-        return emptyReadCloser, err
-    }
+// Same as 'ProduceFrom', but stops crawling once 'ctx' is done. The site map
+// then contains the documents crawled so far, and the context error is returned.
+func (sm *SiteMap) ProduceFromContext(ctx context.Context, startingPoints ...string) error {
 
-    if !requestedUrl.IsAbs() {
-        return emptyReadCloser, errors.New("URL to request should be absolute")
-    }
+    docInfoCh := make(chan crawler.DocInfo, sm.options.DocOutputChSize)
 
-    resp, err := http.Get(requestedUrl.String())
-    if err != nil {
-        return emptyReadCloser, err
+    if len(startingPoints) == 0 {
+        return errors.New("At least one starting point URL is needed")
     }
 
-    return resp.Body, nil
-}
+    sm.roots = nil
+    for _, startingPoint := range startingPoints {
+        startingPointUrl, err := url.ParseRequestURI(startingPoint)
+        if err != nil {
+            return errors.New("Starting point URL " + startingPoint + " is not valid")
+        }
 
-func (sm *SiteMap) ProduceFrom(startingPoint string) error {
+        if root := idFromAbsUrl(startingPointUrl, sm.options.Normalizer); !sm.isRoot(root) {
+            sm.roots = append(sm.roots, root)
+        }
+    }
 
-    docInfoCh := make(chan crawler.DocInfo, docOutputChSize)
+    var listed []crawler.DocId
+    if sm.options.SeedFromSitemaps {
+        sitesRead := make(map [string] bool)
 
-    startingPointUrl, err := url.ParseRequestURI(startingPoint)
-    if err != nil {
-        return errors.New("Starting point URL " + startingPoint + " is not valid")
+        for _, root := range sm.roots {
+            rootUrl, _ := url.Parse(string(root))
+            if sitesRead[rootUrl.Scheme + "://" + rootUrl.Host] {
+                continue
+            }
+            sitesRead[rootUrl.Scheme + "://" + rootUrl.Host] = true
+
+            for _, page := range sm.sitemapSeeds(ctx, rootUrl) {
+                if !sm.listed[page] {
+                    sm.listed[page] = true
+                    listed = append(listed, page)
+                }
+            }
+        }
     }
-    sm.root = idFromAbsUrl(startingPointUrl)
 
-    go sm.crawler.Crawl(sm.root, docInfoCh)
+    resultCh := make(chan crawler.Result, 1)
+    go func() {
+        resultCh <- sm.crawler.CrawlContext(ctx, sm.roots, listed, docInfoCh)
+    }()
 
 loopOverCompletedPages:
     for {
@@ -84,34 +170,211 @@ loopOverCompletedPages:
         }
     }
 
-    return nil
+    sm.result = <- resultCh
+
+    sm.mergeAliases()
+
+    if sm.options.CheckExternalLinks && ctx.Err() == nil {
+        if err := sm.checkExternalLinks(ctx); err != nil {
+            return err
+        }
+    }
+
+    return ctx.Err()
+}
+
+// Replaces the documents merged into other ones, e.g. into their canonical
+// document, by the documents they were merged into: as roots, as seeds of
+// the documents reached from them and as targets of links.
+func (sm *SiteMap) mergeAliases() {
+    primaries := make(map [crawler.DocId] crawler.DocId)
+    for id, doc := range sm.docs {
+        for _, alias := range doc.Aliases {
+            primaries[alias] = id
+        }
+    }
+
+    if len(primaries) == 0 {
+        return
+    }
+
+    var roots []crawler.DocId
+    merged := make(map [crawler.DocId] bool)
+    for _, root := range sm.roots {
+        if primary, isAlias := primaries[root]; isAlias {
+            root = primary
+        }
+
+        if !merged[root] {
+            roots = append(roots, root)
+            merged[root] = true
+        }
+    }
+    sm.roots = roots
+
+    for _, doc := range sm.docs {
+        if primary, isAlias := primaries[doc.Seed]; isAlias {
+            doc.Seed = primary
+        }
+
+        for i, link := range doc.Links {
+            if primary, isAlias := primaries[link]; isAlias {
+                doc.Links[i] = primary
+            }
+        }
+
+        for i, link := range doc.LinkDetails {
+            if primary, isAlias := primaries[link.Target]; isAlias {
+                doc.LinkDetails[i].Target = primary
+            }
+        }
+    }
+}
+
+// Describes why a document has no contents in the site map, or returns
+// an empty string when it has.
+func docProblem(doc *crawler.DocInfo) string {
+    switch {
+        case doc.SkipReason != "":
+            return doc.SkipReason
+        case doc.Error != "":
+            return "error: " + doc.Error
+        case doc.StatusCode >= 300:
+            return fmt.Sprintf("%d %s", doc.StatusCode, http.StatusText(doc.StatusCode))
+        default:
+            return ""
+    }
+}
+
+// Gets the details of the link of a document at 'index' of its links. Only
+// the target is known when the document has no details of its links.
+func linkDetail(doc *crawler.DocInfo, index int) crawler.LinkInfo {
+    if index < len(doc.LinkDetails) && doc.LinkDetails[index].Target == doc.Links[index] {
+        return doc.LinkDetails[index]
+    }
+
+    return crawler.LinkInfo{Target: doc.Links[index]}
+}
+
+// Keys of the metadata of a document, sorted.
+func metadataKeys(doc *crawler.DocInfo) []string {
+    keys := make([]string, 0, len(doc.Metadata))
+    for key := range doc.Metadata {
+        keys = append(keys, key)
+    }
+
+    sort.Strings(keys)
+
+    return keys
+}
+
+func sortedKeys(set map [string] bool) []string {
+    keys := make([]string, 0, len(set))
+    for key := range set {
+        keys = append(keys, key)
+    }
+
+    sort.Strings(keys)
+
+    return keys
+}
+
+// Describes the metadata of a document, with a "key: value" line for every
+// value, sorted by key.
+func metadataLines(doc *crawler.DocInfo) []string {
+    var lines []string
+    for _, key := range metadataKeys(doc) {
+        for _, value := range doc.Metadata[key] {
+            lines = append(lines, key + ": " + strings.Join(strings.Fields(value), " "))
+        }
+    }
+
+    return lines
 }
 
-func (sm *SiteMap) print(
-    fromPageId crawler.DocId,
-    visited map [crawler.DocId] bool,
-    spacing string,
-    level int) {
+// Ids of the starting points of the crawl, in the order they were given.
+func (sm *SiteMap) Roots() []crawler.DocId {
+    return sm.roots
+}
 
-    page := sm.docs[fromPageId]
+func (sm *SiteMap) isRoot(docId crawler.DocId) bool {
+    for _, root := range sm.roots {
+        if root == docId {
+            return true
+        }
+    }
 
-    visited[page.DocId] = true
+    return false
+}
 
-    fmt.Printf("%s- %s\n", spacing, strings.Trim(page.Title, " \t\n"))
+// Gets the information of a crawled document.
+func (sm *SiteMap) Doc(docId crawler.DocId) (doc *crawler.DocInfo, wasCrawled bool) {
+    doc, wasCrawled = sm.docs[docId]
+    return doc, wasCrawled
+}
 
-    for _, link := range page.Links {
-        if _, wasVisited := visited[link]; !wasVisited {
-            sm.print(link, visited, spacing+" ", level+1)
-        } else {
-            fmt.Printf("%s* %s\n", spacing, strings.Trim(sm.docs[link].Title, " \t\n"))
+// Ids of every crawled document, sorted.
+func (sm *SiteMap) DocIds() []crawler.DocId {
+    ids := make([]crawler.DocId, 0, len(sm.docs))
+    for id := range sm.docs {
+        ids = append(ids, id)
+    }
+
+    sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+    return ids
+}
+
+// Ids of the crawled documents reached from each starting point, in the
+// order of the starting points and sorted within each of them.
+func (sm *SiteMap) DocIdsBySeed() [][]crawler.DocId {
+    bySeed := make(map [crawler.DocId] []crawler.DocId)
+    for _, id := range sm.DocIds() {
+        bySeed[sm.docs[id].Seed] = append(bySeed[sm.docs[id].Seed], id)
+    }
+
+    groups := make([][]crawler.DocId, len(sm.roots))
+    for i, root := range sm.roots {
+        groups[i] = bySeed[root]
+    }
+
+    return groups
+}
+
+// Ids of every crawled document and of every document linked from them,
+// even if not crawled, sorted.
+func (sm *SiteMap) NodeIds() []crawler.DocId {
+    nodes := make(map [crawler.DocId] bool)
+    for id, doc := range sm.docs {
+        nodes[id] = true
+        for _, link := range doc.Links {
+            nodes[link] = true
         }
     }
+
+    ids := make([]crawler.DocId, 0, len(nodes))
+    for id := range nodes {
+        ids = append(ids, id)
+    }
+
+    sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+    return ids
 }
 
-func (sm *SiteMap) Print() {
-    fmt.Printf("SITE MAP\n" +
-        " A line starting with a - character indicates the links of the page are just below it.\n" +
-        " A line starting with a * character indicates the page is already in the output and links\n" +
-        " won't be printed again.\n\n\n")
-    sm.print(sm.root, make(map[crawler.DocId]bool), " ", 0)
+// Result of the crawl that produced the site map.
+func (sm *SiteMap) Result() crawler.Result {
+    return sm.result
+}
+
+func (sm *SiteMap) stopReasonDescription() string {
+    switch sm.result.StopReason {
+        case crawler.Cancelled:
+            return "Crawl cancelled before completion."
+        case crawler.LimitReached:
+            return fmt.Sprintf("Crawl stopped by the %s limit, %d links were not followed.",
+                sm.result.Limit, sm.result.LinksSkipped[sm.result.Limit])
+        default:
+            return "Crawl completed."
+    }
 }
\ No newline at end of file
@@ -0,0 +1,111 @@
+package sitemap
+
+import (
+    "io"
+    "strings"
+    "webCrawler/crawler"
+)
+
+// Writes the site map as an indented tree of page titles.
+func writeText(sm *SiteMap, w io.Writer) error {
+    ew := &errWriter{w: w}
+
+    ew.printf("SITE MAP\n" +
+        " A line starting with a - character indicates the links of the page are just below it.\n" +
+        " A line starting with a * character indicates the page is already in the output and links\n" +
+        " won't be printed again.\n\n\n")
+    visited := make(map[crawler.DocId]bool)
+    for i, root := range sm.roots {
+        if len(sm.roots) > 1 {
+            if i > 0 {
+                ew.printf("\n")
+            }
+            ew.printf(" SEED %s\n", root)
+        }
+
+        sm.printText(ew, root, visited, " ", 0)
+    }
+
+    if orphans := sm.Orphans(); len(orphans) > 0 {
+        ew.printf("\n\nORPHAN PAGES\n" +
+            " Pages listed in the sitemaps of the site that no other page links to.\n\n")
+
+        for _, orphan := range orphans {
+            ew.printf(" - %s\n", sm.title(orphan))
+        }
+    }
+
+    metadataHeaderPrinted := false
+    for _, id := range sm.DocIds() {
+        lines := metadataLines(sm.docs[id])
+        if len(lines) == 0 {
+            continue
+        }
+
+        if !metadataHeaderPrinted {
+            ew.printf("\n\nPAGE METADATA\n" +
+                " Description, headings and other metadata found in the pages.\n")
+            metadataHeaderPrinted = true
+        }
+
+        ew.printf("\n - %s\n", id)
+        for _, line := range lines {
+            ew.printf("   %s\n", line)
+        }
+    }
+
+    if externalLinks := sm.ExternalLinks(); len(externalLinks) > 0 {
+        ew.printf("\n\nEXTERNAL LINKS\n" +
+            " Links to documents out of the crawl scope, which were not crawled.\n\n")
+
+        for _, link := range externalLinks {
+            ew.printf(" - %s (%s)\n", link.Target, link.status())
+        }
+    }
+
+    ew.printf("\n\n%s\n", sm.stopReasonDescription())
+
+    return ew.err
+}
+
+func (sm *SiteMap) printText(
+    ew *errWriter,
+    fromPageId crawler.DocId,
+    visited map [crawler.DocId] bool,
+    spacing string,
+    level int) {
+
+    page, wasCrawled := sm.docs[fromPageId]
+
+    visited[fromPageId] = true
+
+    if !wasCrawled {
+        ew.printf("%s- %s (not crawled)\n", spacing, fromPageId)
+        return
+    }
+
+    if problem := docProblem(page); problem != "" {
+        ew.printf("%s- %s (%s)\n", spacing, fromPageId, problem)
+        return
+    }
+
+    ew.printf("%s- %s\n", spacing, strings.Trim(page.Title, " \t\n"))
+
+    for _, link := range page.Links {
+        if _, wasVisited := visited[link]; !wasVisited {
+            sm.printText(ew, link, visited, spacing+" ", level+1)
+        } else {
+            ew.printf("%s* %s\n", spacing, sm.title(link))
+        }
+    }
+}
+
+func (sm *SiteMap) title(docId crawler.DocId) string {
+    if doc, wasCrawled := sm.docs[docId]; wasCrawled && docProblem(doc) != "" {
+        return string(docId) + " (" + docProblem(doc) + ")"
+    } else if wasCrawled {
+        return strings.Trim(doc.Title, " \t\n")
+    }
+
+    return string(docId)
+}
@@ -3,40 +3,56 @@ package sitemap
 import (
     "net/url"
     "webCrawler/crawler"
+    "webCrawler/normalize"
 )
 
-func idFromAbsUrl(url *url.URL) crawler.DocId {
+// Gets the id of the document at an absolute URL, so that the different
+// URLs of the same document share the id.
+func idFromAbsUrl(url *url.URL, normalizer normalize.Normalizer) crawler.DocId {
+    return crawler.DocId(normalizer.Normalize(url).String())
+}
 
-    // Remove the elements of the URL that could be different for
-    // the same page
-    url.RawQuery = ""
-    url.Fragment = ""
-    url.User = nil
+func idFromLocator(
+    locator crawler.Loc,
+    from crawler.DocId,
+    normalizer normalize.Normalizer) (id crawler.DocId, idIsValid bool) {
 
-    // Do not show '?' character on empty queries
-    url.ForceQuery = false
+    parentUrl, parentUrlError := url.ParseRequestURI(string(from))
+    locatorAbsUrl, locatorUrlError := parentUrl.Parse(string(locator))
 
-    // Remove trailing '/' characters on URL
-    for last := len(url.Path)-1; last >= 0 && url.Path[last] == '/'; last = len(url.Path)-1 {
-        url.Path = url.Path[:len(url.Path)-1]
+    if parentUrlError != nil || locatorUrlError != nil {
+        return "", false
     }
 
-    return crawler.DocId(url.String())
+    return idFromAbsUrl(locatorAbsUrl, normalizer), true
 }
 
-func idFromLocator(locator crawler.Loc, from crawler.DocId) (id crawler.DocId, idIsValid bool) {
-    parentUrl, parentUrlError := url.ParseRequestURI(string(from))
-    locatorAbsUrl, locatorUrlError := parentUrl.Parse(string(locator))
+// Resolver of the links between web documents, which have their URL as id.
+type urlResolver struct {
+    normalizer normalize.Normalizer
+}
 
-    if parentUrlError != nil || locatorUrlError != nil {
+func (r urlResolver) Resolve(locator crawler.Loc, from crawler.DocId) (crawler.DocId, bool) {
+    return idFromLocator(locator, from, r.normalizer)
+}
+
+// Gets the absolute URL of a link in a document with a base URL, which
+// may be relative to the document URL.
+func (r urlResolver) ResolveBase(locator crawler.Loc, base crawler.Loc, from crawler.DocId) (crawler.Loc, bool) {
+    parentUrl, err := url.ParseRequestURI(string(from))
+    if err != nil {
         return "", false
     }
 
-    // Ignore different domains or same domain from a different protocol
-    if locatorAbsUrl.Hostname() != parentUrl.Hostname() ||
-        locatorAbsUrl.Scheme != parentUrl.Scheme {
+    baseUrl, err := parentUrl.Parse(string(base))
+    if err != nil {
         return "", false
     }
 
-    return idFromAbsUrl(locatorAbsUrl), true
-}
\ No newline at end of file
+    locatorAbsUrl, err := baseUrl.Parse(string(locator))
+    if err != nil {
+        return "", false
+    }
+
+    return crawler.Loc(locatorAbsUrl.String()), true
+}
@@ -0,0 +1,26 @@
+package sitemap
+
+import (
+    "github.com/stretchr/testify/assert"
+    "testing"
+    "webCrawler/crawler"
+    "webCrawler/normalize"
+)
+
+func TestShouldResolveLinksAgainstBase(t *testing.T) {
+    assert := assert.New(t)
+
+    resolver := urlResolver{normalize.Default()}
+    from := crawler.DocId("http://example.com/blog/post")
+
+    locator, isValid := resolver.ResolveBase("page?id=1", "/docs/", from)
+    assert.True(isValid)
+    assert.Equal(crawler.Loc("http://example.com/docs/page?id=1"), locator)
+
+    locator, isValid = resolver.ResolveBase("../page", "http://cdn.example.com/a/b/", from)
+    assert.True(isValid)
+    assert.Equal(crawler.Loc("http://cdn.example.com/a/page"), locator)
+
+    _, isValid = resolver.ResolveBase("page", "http://[::1", from)
+    assert.False(isValid)
+}
@@ -0,0 +1,91 @@
+package sitemap
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "sort"
+    "sync"
+)
+
+type Writer interface {
+    // Writes the site map 'sm' to 'w' in some output format.
+    Write(sm *SiteMap, w io.Writer) error
+}
+
+type writerFuncImpl struct {
+    write func(sm *SiteMap, w io.Writer) error
+}
+
+func (wfi writerFuncImpl) Write(sm *SiteMap, w io.Writer) error {
+    return wfi.write(sm, w)
+}
+
+func WriterFunc(write func(sm *SiteMap, w io.Writer) error) Writer {
+    return writerFuncImpl{write}
+}
+
+var writers = map [string] Writer {
+    "text": WriterFunc(writeText),
+    "json": WriterFunc(writeJson),
+    "csv": WriterFunc(writeCsv),
+    "dot": WriterFunc(writeDot),
+    "graphml": WriterFunc(writeGraphMl),
+}
+var writersMutex sync.RWMutex
+
+// Makes 'writer' available under the 'format' name, replacing the writer
+// previously registered with that name, if any.
+func RegisterWriter(format string, writer Writer) {
+    writersMutex.Lock()
+    defer writersMutex.Unlock()
+
+    writers[format] = writer
+}
+
+// Names of the registered output formats, sorted.
+func Formats() []string {
+    writersMutex.RLock()
+    defer writersMutex.RUnlock()
+
+    formats := make([]string, 0, len(writers))
+    for format := range writers {
+        formats = append(formats, format)
+    }
+
+    sort.Strings(formats)
+
+    return formats
+}
+
+// Writes the site map to 'w' using the writer registered for 'format'.
+func (sm *SiteMap) Write(format string, w io.Writer) error {
+    writersMutex.RLock()
+    writer, exists := writers[format]
+    writersMutex.RUnlock()
+
+    if !exists {
+        return errors.New("unknown output format '" + format + "'")
+    }
+
+    return writer.Write(sm, w)
+}
+
+// Prints the site map as an indented text tree to the standard output.
+func (sm *SiteMap) Print() {
+    _ = sm.Write("text", os.Stdout)
+}
+
+// Writer keeping the first error found, so a sequence of writes can be
+// checked just once at the end.
+type errWriter struct {
+    w   io.Writer
+    err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+    if ew.err == nil {
+        _, ew.err = fmt.Fprintf(ew.w, format, args...)
+    }
+}
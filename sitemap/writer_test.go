@@ -0,0 +1,184 @@
+package sitemap
+
+import (
+    "bytes"
+    "encoding/json"
+    "encoding/xml"
+    "github.com/stretchr/testify/assert"
+    "io"
+    "strings"
+    "testing"
+    "webCrawler/crawler"
+)
+
+func testSiteMap() *SiteMap {
+    root := crawler.DefaultDocInfo("http://example.com")
+    root.Title = "Home"
+    root.StatusCode = 200
+    root.Links = []crawler.DocId{"http://example.com/a", "http://example.com/missing"}
+    root.Metadata = map [string] []string{"description": {"The home page"}, "h1": {"Home", "Welcome"}}
+
+    a := crawler.DefaultDocInfo("http://example.com/a")
+    a.Title = "Page \"A\""
+    a.StatusCode = 200
+    a.Depth = 1
+    a.Links = []crawler.DocId{"http://example.com", "http://example.com/b"}
+    a.LinkDetails = []crawler.LinkInfo{
+        {Target: "http://example.com", LinkAttributes: crawler.LinkAttributes{Text: "Home", Position: 1}},
+        {Target: "http://example.com/b", LinkAttributes: crawler.LinkAttributes{
+            Text: "B", Title: "Page B", Rel: []string{"next"}, Position: 3}},
+    }
+
+    missing := crawler.DefaultDocInfo("http://example.com/missing")
+    missing.StatusCode = 404
+    missing.Depth = 1
+
+    for _, doc := range []*crawler.DocInfo{root, a, missing} {
+        doc.Seed = root.DocId
+    }
+
+    return &SiteMap{
+        docs: map [crawler.DocId] *crawler.DocInfo {
+            root.DocId: root,
+            a.DocId: a,
+            missing.DocId: missing,
+        },
+        roots: []crawler.DocId{root.DocId},
+        result: crawler.Result{StopReason: crawler.Completed},
+    }
+}
+
+func TestShouldWriteJson(t *testing.T) {
+    assert := assert.New(t)
+
+    var out bytes.Buffer
+    assert.Nil(testSiteMap().Write("json", &out))
+
+    var written jsonSiteMap
+    assert.Nil(json.Unmarshal(out.Bytes(), &written))
+
+    assert.Equal([]string{"http://example.com"}, written.Roots)
+    assert.Equal("Completed", written.Result.StopReason)
+
+    // Linked but not crawled documents are nodes too
+    assert.Equal(4, len(written.Nodes))
+    assert.Equal("Home", written.Nodes[0].Title)
+    assert.Equal([]string{"http://example.com/a", "http://example.com/missing"}, written.Nodes[0].Links)
+    assert.Equal([]string{"Home", "Welcome"}, written.Nodes[0].Metadata["h1"])
+    assert.Equal(404, written.Nodes[3].StatusCode)
+    assert.Equal(jsonLink{"http://example.com/b", "B", "Page B", []string{"next"}, 3}, written.Nodes[1].LinkDetails[1])
+    assert.Equal(jsonLink{Target: "http://example.com/a"}, written.Nodes[0].LinkDetails[0])
+    assert.False(written.Nodes[2].Crawled)
+}
+
+func TestShouldWriteCsvEdges(t *testing.T) {
+    assert := assert.New(t)
+
+    var out bytes.Buffer
+    assert.Nil(testSiteMap().Write("csv", &out))
+
+    assert.Equal("seed,source,target,target_status,target_metadata,anchor_text,link_title,link_rel,link_position\n" +
+        "http://example.com,http://example.com,http://example.com/a,200,,,,,\n" +
+        "http://example.com,http://example.com,http://example.com/missing,404,,,,,\n" +
+        "http://example.com,http://example.com/a,http://example.com,200," +
+            `"{""description"":[""The home page""],""h1"":[""Home"",""Welcome""]}"` + ",Home,,,1\n" +
+        "http://example.com,http://example.com/a,http://example.com/b,,,B,Page B,next,3\n", out.String())
+}
+
+func TestShouldWriteDot(t *testing.T) {
+    assert := assert.New(t)
+
+    var out bytes.Buffer
+    assert.Nil(testSiteMap().Write("dot", &out))
+
+    dot := out.String()
+    assert.True(strings.HasPrefix(dot, "digraph sitemap {"))
+    assert.Contains(dot, `"http://example.com/a" [label="Page \"A\""];`)
+    assert.Contains(dot, `"http://example.com/a" -> "http://example.com/b";`)
+    assert.Contains(dot, `tooltip="description: The home page\nh1: Home\nh1: Welcome"`)
+    assert.Contains(dot, `"http://example.com" [label="Home", style="bold", tooltip=`)
+    assert.Contains(dot, `"http://example.com/missing" [label="http://example.com/missing (404 Not Found)", color=red];`)
+
+    for _, line := range strings.Split(dot, "\n") {
+        assert.LessOrEqual(strings.Count(line, "style="), 1, "Expected a single style in '%s'", line)
+    }
+}
+
+func TestShouldGroupOutputBySeed(t *testing.T) {
+    assert := assert.New(t)
+
+    sm := testSiteMap()
+
+    other := crawler.DefaultDocInfo("http://other.com")
+    other.Title = "Other"
+    other.StatusCode = 200
+    other.Seed = other.DocId
+    sm.docs[other.DocId] = other
+    sm.roots = append(sm.roots, other.DocId)
+
+    var out bytes.Buffer
+    assert.Nil(sm.Write("text", &out))
+    assert.Contains(out.String(), " SEED http://example.com\n - Home\n")
+    assert.Contains(out.String(), " SEED http://other.com\n - Other\n")
+
+    out.Reset()
+    assert.Nil(sm.Write("dot", &out))
+    assert.Contains(out.String(), "    subgraph \"cluster_1\" {\n" +
+        "        label=\"http://other.com\";\n" +
+        "        \"http://other.com\" [label=\"Other\", style=\"bold\"];\n" +
+        "    }\n")
+}
+
+func TestShouldReplaceMergedDocuments(t *testing.T) {
+    assert := assert.New(t)
+
+    // Both starting points declare the home page as canonical
+    sm := testSiteMap()
+    home := sm.docs["http://example.com"]
+    delete(sm.docs, home.DocId)
+    home.DocId = "http://example.com/home"
+    home.Aliases = []crawler.DocId{"http://example.com", "http://www.example.com"}
+    sm.docs[home.DocId] = home
+    sm.roots = []crawler.DocId{"http://example.com", "http://www.example.com"}
+
+    sm.mergeAliases()
+
+    assert.Equal([]crawler.DocId{"http://example.com/home"}, sm.Roots())
+    assert.Equal([][]crawler.DocId{{"http://example.com/a", "http://example.com/home", "http://example.com/missing"}},
+        sm.DocIdsBySeed())
+
+    var out bytes.Buffer
+    assert.Nil(sm.Write("text", &out))
+    assert.Contains(out.String(), " - Home\n  - Page \"A\"\n  * Home\n")
+    assert.NotContains(out.String(), "- http://example.com (not crawled)")
+    assert.Equal(home.DocId, sm.docs["http://example.com/a"].LinkDetails[0].Target)
+}
+
+func TestShouldWriteValidGraphMl(t *testing.T) {
+    assert := assert.New(t)
+
+    var out bytes.Buffer
+    assert.Nil(testSiteMap().Write("graphml", &out))
+
+    var written graphMl
+    assert.Nil(xml.Unmarshal(out.Bytes(), &written))
+    assert.Equal(4, len(written.Graph.Nodes))
+    assert.Equal(4, len(written.Graph.Edges))
+    assert.Contains(written.Graph.Nodes[0].Data, graphMlData{"metadata:h1", "Home\nWelcome"})
+}
+
+func TestShouldUseRegisteredWriters(t *testing.T) {
+    assert := assert.New(t)
+
+    RegisterWriter("count", WriterFunc(func(sm *SiteMap, w io.Writer) error {
+        _, err := io.WriteString(w, string(rune('0' + len(sm.DocIds()))))
+        return err
+    }))
+
+    var out bytes.Buffer
+    assert.Nil(testSiteMap().Write("count", &out))
+    assert.Equal("3", out.String())
+    assert.Contains(Formats(), "count")
+
+    assert.NotNil(testSiteMap().Write("unknown", &out))
+}
@@ -0,0 +1,338 @@
+package sitemap
+
+import (
+    "bytes"
+    "compress/gzip"
+    "encoding/xml"
+    "errors"
+    "fmt"
+    "io"
+    "net/url"
+    "os"
+    "path/filepath"
+    "strings"
+    "time"
+    "webCrawler/crawler"
+)
+
+const xmlSitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+// Limits of a single sitemap file set by the sitemaps.org protocol.
+const maxUrlsPerXmlSitemap = 50000
+const maxBytesPerXmlSitemap = 50 * 1024 * 1024
+
+var changeFreqs = []string{"always", "hourly", "daily", "weekly", "monthly", "yearly", "never"}
+
+type XmlSitemapOptions struct {
+    // Directory where the sitemap files are written.
+    Dir string
+
+    // URL the sitemap files will be published under, used by the sitemap
+    // index to locate them. When empty, the root of the site of the listed
+    // pages is used. With pages of several sites, the files of each site
+    // are under a subdirectory of this URL, or else under its own root.
+    BaseUrl string
+
+    // Compress the sitemap files with gzip, adding a '.gz' extension.
+    Gzip bool
+
+    // Change frequency of every page, one of the values of the protocol, e.g.
+    // "daily". Omitted when empty.
+    ChangeFreq string
+
+    // Maximum number of URLs and uncompressed bytes of a single sitemap file.
+    // When exceeded, the URLs are split in several files listed by an index.
+    MaxUrlsPerFile  int
+    MaxBytesPerFile int
+}
+
+func DefaultXmlSitemapOptions() XmlSitemapOptions {
+    return XmlSitemapOptions{
+        Dir: ".",
+        BaseUrl: "",
+        Gzip: false,
+        ChangeFreq: "",
+        MaxUrlsPerFile: maxUrlsPerXmlSitemap,
+        MaxBytesPerFile: maxBytesPerXmlSitemap,
+    }
+}
+
+func IsChangeFreq(changeFreq string) bool {
+    for _, cf := range changeFreqs {
+        if cf == changeFreq {
+            return true
+        }
+    }
+
+    return false
+}
+
+type xmlSitemapUrl struct {
+    XMLName    xml.Name `xml:"url"`
+    Loc        string   `xml:"loc"`
+    LastMod    string   `xml:"lastmod,omitempty"`
+    ChangeFreq string   `xml:"changefreq,omitempty"`
+    Priority   string   `xml:"priority,omitempty"`
+}
+
+type xmlSitemapRef struct {
+    XMLName xml.Name `xml:"sitemap"`
+    Loc     string   `xml:"loc"`
+    LastMod string   `xml:"lastmod,omitempty"`
+}
+
+// Priority of a page given its depth, 1.0 for the starting point and
+// lowering 0.2 with each level down to 0.2.
+func xmlSitemapPriority(depth int) string {
+    priority := 10 - 2 * depth
+    if priority < 2 {
+        priority = 2
+    }
+
+    return fmt.Sprintf("%d.%d", priority / 10, priority % 10)
+}
+
+// Whether a crawled document should be listed in the XML sitemap, which
+// only lists the pages successfully fetched that do not ask not to be
+// indexed, and that are their own canonical page.
+func inXmlSitemap(doc *crawler.DocInfo) bool {
+    isCanonical := doc.Canonical == "" || doc.Canonical == doc.DocId || doc.Canonical == doc.FinalId(doc.DocId)
+
+    return docProblem(doc) == "" && doc.StatusCode >= 200 && doc.StatusCode < 300 && !doc.NoIndex && isCanonical
+}
+
+// Writes the site map in the sitemaps.org XML format into 'options.Dir'.
+// When the pages don't fit in a single file, they are split in several
+// 'sitemap-N.xml' files and a 'sitemap.xml' index listing them is written.
+// As a sitemap only lists the URLs of its own site, the pages of each site,
+// i.e. of each protocol and host, are written in a subdirectory named after
+// it when there are several. Returns the paths of the written files, the
+// index or single file of each site first.
+func (sm *SiteMap) WriteXmlSitemaps(options XmlSitemapOptions) ([]string, error) {
+    if options.ChangeFreq != "" && !IsChangeFreq(options.ChangeFreq) {
+        return nil, errors.New("unknown change frequency '" + options.ChangeFreq + "'")
+    }
+
+    if options.MaxUrlsPerFile < 1 || options.MaxUrlsPerFile > maxUrlsPerXmlSitemap {
+        options.MaxUrlsPerFile = maxUrlsPerXmlSitemap
+    }
+
+    if options.MaxBytesPerFile < 1 || options.MaxBytesPerFile > maxBytesPerXmlSitemap {
+        options.MaxBytesPerFile = maxBytesPerXmlSitemap
+    }
+
+    entries, sites := sm.xmlSitemapEntries(options.ChangeFreq)
+
+    if len(sites) <= 1 {
+        site := ""
+        if len(sites) == 1 {
+            site = sites[0]
+        }
+
+        baseUrl, err := sm.xmlSitemapBaseUrl(options.BaseUrl, site)
+        if err != nil {
+            return nil, err
+        }
+
+        return writeXmlSitemapSet(options.Dir, baseUrl, entries[site], options)
+    }
+
+    var paths []string
+    for _, site := range sites {
+        name := xmlSitemapDirName(site)
+        dir := filepath.Join(options.Dir, name)
+        if err := os.MkdirAll(dir, 0755); err != nil {
+            return paths, err
+        }
+
+        baseUrl := site + "/"
+        if options.BaseUrl != "" {
+            baseUrl = strings.TrimSuffix(options.BaseUrl, "/") + "/" + name + "/"
+        }
+
+        sitePaths, err := writeXmlSitemapSet(dir, baseUrl, entries[site], options)
+        paths = append(paths, sitePaths...)
+        if err != nil {
+            return paths, err
+        }
+    }
+
+    return paths, nil
+}
+
+// Gets the entries of the pages listed in the XML sitemap by site, i.e. by
+// the protocol and host of their URL, e.g. "https://example.com", along
+// with the sites in the order their first page was found.
+func (sm *SiteMap) xmlSitemapEntries(changeFreq string) (map [string] []xmlSitemapUrl, []string) {
+    entries := make(map [string] []xmlSitemapUrl)
+    var sites []string
+    listed := make(map [crawler.DocId] bool)
+
+    for _, id := range sm.DocIds() {
+        doc := sm.docs[id]
+        if !inXmlSitemap(doc) {
+            continue
+        }
+
+        // Redirected documents are listed at the URL they were redirected
+        // to, unless it was crawled on its own
+        loc := doc.FinalId(doc.DocId)
+        if _, wasCrawled := sm.docs[loc]; (loc != id && wasCrawled) || listed[loc] {
+            continue
+        }
+        listed[loc] = true
+
+        entry := xmlSitemapUrl{
+            Loc: string(loc),
+            ChangeFreq: changeFreq,
+            Priority: xmlSitemapPriority(doc.Depth),
+        }
+        if !doc.LastModified.IsZero() {
+            entry.LastMod = doc.LastModified.UTC().Format(time.RFC3339)
+        }
+
+        site := ""
+        if locUrl, err := url.Parse(string(loc)); err == nil && locUrl.IsAbs() {
+            site = locUrl.Scheme + "://" + locUrl.Host
+        }
+
+        if _, exists := entries[site]; !exists {
+            sites = append(sites, site)
+        }
+        entries[site] = append(entries[site], entry)
+    }
+
+    return entries, sites
+}
+
+// Name of the directory of the sitemap files of a site, e.g.
+// "https_example.com" for "https://example.com".
+func xmlSitemapDirName(site string) string {
+    return strings.NewReplacer("://", "_", ":", "_").Replace(site)
+}
+
+// Writes the sitemap files of the entries of a single site into 'dir', the
+// index listing them at 'baseUrl' when they are split in several files.
+func writeXmlSitemapSet(dir string, baseUrl string, entries []xmlSitemapUrl, options XmlSitemapOptions) ([]string, error) {
+    header := xml.Header + `<urlset xmlns="` + xmlSitemapNamespace + `">` + "\n"
+    footer := "</urlset>\n"
+
+    var files [][]byte
+    current := bytes.NewBufferString(header)
+    urlsInCurrent := 0
+
+    for _, entry := range entries {
+        entryXml, err := xml.Marshal(entry)
+        if err != nil {
+            return nil, err
+        }
+        entryXml = append(entryXml, '\n')
+
+        if urlsInCurrent == options.MaxUrlsPerFile ||
+            (urlsInCurrent > 0 && current.Len() + len(entryXml) + len(footer) > options.MaxBytesPerFile) {
+
+            current.WriteString(footer)
+            files = append(files, current.Bytes())
+
+            current = bytes.NewBufferString(header)
+            urlsInCurrent = 0
+        }
+
+        current.Write(entryXml)
+        urlsInCurrent++
+    }
+
+    current.WriteString(footer)
+    files = append(files, current.Bytes())
+
+    extension := ".xml"
+    if options.Gzip {
+        extension += ".gz"
+    }
+
+    if len(files) == 1 {
+        path := filepath.Join(dir, "sitemap" + extension)
+        return []string{path}, writeXmlSitemapFile(path, files[0], options.Gzip)
+    }
+
+    index := bytes.NewBufferString(xml.Header + `<sitemapindex xmlns="` + xmlSitemapNamespace + `">` + "\n")
+    paths := []string{filepath.Join(dir, "sitemap" + extension)}
+    now := time.Now().UTC().Format(time.RFC3339)
+
+    for i, contents := range files {
+        name := fmt.Sprintf("sitemap-%d%s", i+1, extension)
+        path := filepath.Join(dir, name)
+
+        if err := writeXmlSitemapFile(path, contents, options.Gzip); err != nil {
+            return paths, err
+        }
+        paths = append(paths, path)
+
+        refXml, err := xml.Marshal(xmlSitemapRef{Loc: baseUrl + name, LastMod: now})
+        if err != nil {
+            return paths, err
+        }
+        index.Write(refXml)
+        index.WriteString("\n")
+    }
+
+    index.WriteString("</sitemapindex>\n")
+
+    return paths, writeXmlSitemapFile(paths[0], index.Bytes(), options.Gzip)
+}
+
+// Gets the URL the sitemap files are published under, ending with '/'. By
+// default, the root of 'site', or else of the site of the first starting
+// point.
+func (sm *SiteMap) xmlSitemapBaseUrl(baseUrl string, site string) (string, error) {
+    if baseUrl == "" && site != "" {
+        baseUrl = site
+    }
+
+    if baseUrl == "" {
+        if len(sm.roots) == 0 {
+            return "", errors.New("sitemap base URL is needed when there is no starting point")
+        }
+
+        rootUrl, err := url.Parse(string(sm.roots[0]))
+        if err != nil || !rootUrl.IsAbs() {
+            return "", errors.New("sitemap base URL is needed when the root is not an absolute URL")
+        }
+
+        baseUrl = rootUrl.Scheme + "://" + rootUrl.Host
+    }
+
+    if !strings.HasSuffix(baseUrl, "/") {
+        baseUrl += "/"
+    }
+
+    return baseUrl, nil
+}
+
+func writeXmlSitemapFile(path string, contents []byte, compress bool) error {
+    file, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+
+    var out io.Writer = file
+    var gzipOut *gzip.Writer
+    if compress {
+        gzipOut = gzip.NewWriter(file)
+        out = gzipOut
+    }
+
+    _, err = out.Write(contents)
+
+    if gzipOut != nil {
+        if closeErr := gzipOut.Close(); err == nil {
+            err = closeErr
+        }
+    }
+
+    if closeErr := file.Close(); err == nil {
+        err = closeErr
+    }
+
+    return err
+}
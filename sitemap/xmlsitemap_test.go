@@ -0,0 +1,135 @@
+package sitemap
+
+import (
+    "compress/gzip"
+    "github.com/stretchr/testify/assert"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+    "webCrawler/crawler"
+)
+
+func TestShouldWriteSingleXmlSitemap(t *testing.T) {
+    assert := assert.New(t)
+
+    sm := testSiteMap()
+    sm.docs["http://example.com/a"].LastModified = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+    options := DefaultXmlSitemapOptions()
+    options.Dir = t.TempDir()
+    options.ChangeFreq = "weekly"
+
+    paths, err := sm.WriteXmlSitemaps(options)
+    assert.Nil(err)
+    assert.Equal([]string{filepath.Join(options.Dir, "sitemap.xml")}, paths)
+
+    contents, _ := ioutil.ReadFile(paths[0])
+    xmlStr := string(contents)
+
+    assert.Contains(xmlStr, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+    assert.Contains(xmlStr, "<url><loc>http://example.com</loc><changefreq>weekly</changefreq><priority>1.0</priority></url>")
+    assert.Contains(xmlStr, "<url><loc>http://example.com/a</loc><lastmod>2020-01-02T03:04:05Z</lastmod>" +
+        "<changefreq>weekly</changefreq><priority>0.8</priority></url>")
+
+    // Error pages are not listed
+    assert.NotContains(xmlStr, "http://example.com/missing")
+}
+
+func TestShouldListOnlyCanonicalUrlsInXmlSitemap(t *testing.T) {
+    assert := assert.New(t)
+
+    sm := testSiteMap()
+    sm.docs["http://example.com"].RedirectChain = []crawler.DocId{"http://example.com/home"}
+    sm.docs["http://example.com/a"].RedirectChain = []crawler.DocId{"http://example.com/b"}
+
+    b := crawler.DefaultDocInfo("http://example.com/b")
+    b.StatusCode = 200
+    b.Canonical = "http://example.com/b"
+    sm.docs[b.DocId] = b
+
+    print := crawler.DefaultDocInfo("http://example.com/print")
+    print.StatusCode = 200
+    print.Canonical = "http://example.com/article"
+    sm.docs[print.DocId] = print
+
+    options := DefaultXmlSitemapOptions()
+    options.Dir = t.TempDir()
+
+    paths, err := sm.WriteXmlSitemaps(options)
+    assert.Nil(err)
+
+    contents, _ := ioutil.ReadFile(paths[0])
+    xmlStr := string(contents)
+
+    assert.Contains(xmlStr, "<loc>http://example.com/home</loc>", "Expected the redirect target to be listed")
+    assert.NotContains(xmlStr, "<loc>http://example.com</loc>")
+    assert.NotContains(xmlStr, "<loc>http://example.com/a</loc>")
+    assert.Equal(1, strings.Count(xmlStr, "<loc>http://example.com/b</loc>"))
+    assert.NotContains(xmlStr, "http://example.com/print", "Expected non-canonical pages not to be listed")
+}
+
+func TestShouldSplitXmlSitemapsWithIndex(t *testing.T) {
+    assert := assert.New(t)
+
+    options := DefaultXmlSitemapOptions()
+    options.Dir = t.TempDir()
+    options.BaseUrl = "https://cdn.example.com/maps"
+    options.Gzip = true
+    options.MaxUrlsPerFile = 1
+
+    paths, err := testSiteMap().WriteXmlSitemaps(options)
+    assert.Nil(err)
+    assert.Equal(3, len(paths))
+
+    file, _ := os.Open(paths[0])
+    defer file.Close()
+    reader, err := gzip.NewReader(file)
+    assert.Nil(err)
+    contents, _ := ioutil.ReadAll(reader)
+
+    assert.True(strings.Contains(string(contents), "<sitemapindex"))
+    assert.Contains(string(contents), "<loc>https://cdn.example.com/maps/sitemap-1.xml.gz</loc>")
+    assert.Contains(string(contents), "<loc>https://cdn.example.com/maps/sitemap-2.xml.gz</loc>")
+}
+
+func TestShouldWriteXmlSitemapsOfEachSite(t *testing.T) {
+    assert := assert.New(t)
+
+    sm := testSiteMap()
+    other := crawler.DefaultDocInfo("https://other.com")
+    other.StatusCode = 200
+    sm.docs[other.DocId] = other
+    sm.roots = append(sm.roots, other.DocId)
+
+    options := DefaultXmlSitemapOptions()
+    options.Dir = t.TempDir()
+    options.MaxUrlsPerFile = 1
+
+    paths, err := sm.WriteXmlSitemaps(options)
+    assert.Nil(err)
+    assert.Equal([]string{
+        filepath.Join(options.Dir, "http_example.com", "sitemap.xml"),
+        filepath.Join(options.Dir, "http_example.com", "sitemap-1.xml"),
+        filepath.Join(options.Dir, "http_example.com", "sitemap-2.xml"),
+        filepath.Join(options.Dir, "https_other.com", "sitemap.xml"),
+    }, paths)
+
+    index, _ := ioutil.ReadFile(paths[0])
+    assert.Contains(string(index), "<loc>http://example.com/sitemap-1.xml</loc>")
+
+    otherSitemap, _ := ioutil.ReadFile(paths[3])
+    assert.Contains(string(otherSitemap), "<loc>https://other.com</loc>")
+    assert.NotContains(string(otherSitemap), "example.com")
+}
+
+func TestShouldRejectUnknownChangeFreq(t *testing.T) {
+    options := DefaultXmlSitemapOptions()
+    options.Dir = t.TempDir()
+    options.ChangeFreq = "sometimes"
+
+    _, err := testSiteMap().WriteXmlSitemaps(options)
+    assert.NotNil(t, err)
+}
@@ -31,6 +31,7 @@ func NewFixed(capacity int) (Pool, error) {
             make(chan struct{}),
         })
 
+        wg.Add(1)
         go workers[i].run()
     }
 
@@ -76,4 +76,17 @@ func TestShouldRunMoreTasksThanItsCapacity(t *testing.T) {
     close(taskRanCh)
 
     assert.Equal(tasksToRun, tasksRan, "Expected to ran %d tasks")
-}
\ No newline at end of file
+}
+
+func TestShouldStopRightAfterStarting(t *testing.T) {
+    assert := assert.New(t)
+
+    for i:=0; i<100; i++ {
+        pool, error := NewFixed(8)
+        assert.NotNil(pool, "Expected pool to not be nil")
+        assert.Nil(error, "Expected error to be nil")
+
+        // Stopping waits for every worker, even the ones not started yet
+        pool.Stop()
+    }
+}
@@ -34,6 +34,7 @@ func NewVari(initialCapacity int,
             make(chan struct{}),
         })
 
+        wg.Add(1)
         go workers[i].run()
     }
 
@@ -92,6 +93,7 @@ func (p VariPool) adjustCapacity(newCap int) {
                 make(chan struct{}),
             })
 
+            p.wg.Add(1)
             go p.workers[len(p.workers) - 1].run()
         }
     }
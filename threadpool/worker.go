@@ -13,10 +13,10 @@ type worker struct {
     stopAcceptingTasksCh chan struct{}
 }
 
+// Runs the tasks received by the worker. The wait group should be
+// incremented before starting it.
 func (w *worker) run() {
 
-    w.wg.Add(1)
-
 workerLoop:
     for {
         select {